@@ -1,16 +1,57 @@
 package main
 
 import (
+	"context"
+	"github.com/ivanspasov99/golang-api/pkg/config"
 	"github.com/ivanspasov99/golang-api/pkg/job"
 	"github.com/ivanspasov99/golang-api/pkg/logging"
+	"github.com/ivanspasov99/golang-api/pkg/run"
 	"github.com/rs/zerolog/log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
+// shutdownTimeout is added to the grace period of the stopped commands when the server shuts down
+const shutdownTimeout = 5 * time.Second
+
 func main() {
+	if err := config.InitConfig(); err != nil {
+		log.Fatal().Msg(err.Error())
+	}
+
 	http.HandleFunc("/job", logging.DecorateHeader(job.HandleError(job.Handle)))
+	http.HandleFunc("/modes", logging.DecorateHeader(job.HandleError(job.HandleModes)))
 
-	if err := http.ListenAndServe(":8080", nil); err != nil {
-		log.Fatal().Msg(err.Error())
+	runConfig := config.AppConfig().Run
+	runs := run.NewManager(run.ShellRunner{Shell: runConfig.Shell, GracePeriod: runConfig.GracePeriod}, run.Options{
+		MaxParallelism: runConfig.MaxParallelism,
+		LogLines:       runConfig.LogLines,
+		Retention:      runConfig.Retention,
+	})
+	http.HandleFunc("/jobs/", logging.DecorateHeader(run.HandleError(runs.HandleCreate)))
+	http.HandleFunc("/runs/", logging.DecorateHeader(run.HandleError(runs.HandleRuns)))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{Addr: ":8080"}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal().Msg(err.Error())
+		}
+	}()
+	<-ctx.Done()
+
+	// the runs are cancelled first, so their commands are not left running and the log streams end
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), runConfig.GracePeriod+shutdownTimeout)
+	defer cancel()
+	if err := runs.Shutdown(shutdownCtx); err != nil {
+		log.Error().Msg(err.Error())
+	}
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Error().Msg(err.Error())
 	}
 }
@@ -6,6 +6,7 @@ import (
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+	"time"
 )
 
 var appConfig Config
@@ -18,6 +19,25 @@ type Config struct {
 		Name string `envconfig:"default=image-name"`
 		Tag  string `envconfig:"default=tag-release"`
 	}
+	// K8s configures the k8s mode manifests
+	K8s struct {
+		Namespace string `envconfig:"default=default"`
+		// WaitImage should contain kubectl, it is used to wait for the required tasks
+		WaitImage string `envconfig:"default=bitnami/kubectl:1.26"`
+	}
+	// Run configures the execution of the job runs
+	Run struct {
+		// MaxParallelism is the maximum number of tasks running at the same time in all runs, 0 is unlimited
+		MaxParallelism int `envconfig:"default=0"`
+		// LogLines is the number of the last output lines kept for every run
+		LogLines int `envconfig:"default=10000"`
+		// Shell runs the task commands with `-c`
+		Shell string `envconfig:"default=bash"`
+		// GracePeriod is the time between SIGTERM and SIGKILL when the commands of cancelled run are stopped
+		GracePeriod time.Duration `envconfig:"default=10s"`
+		// Retention is how long the finished run is kept in memory
+		Retention time.Duration `envconfig:"default=1h"`
+	}
 	Region      string `envconfig:"default=region"`
 	Environment string `envconfig:"default=env"`
 }
@@ -0,0 +1,139 @@
+package graph
+
+import (
+	"sort"
+)
+
+// StronglyConnectedComponents returns the keys of the strongly connected components using Tarjan's algorithm
+// Components are returned in reverse topological order, vertices in a component are in the order they were visited
+func (g *DirectedGraph[K, V]) StronglyConnectedComponents() [][]K {
+	components := g.components()
+	result := make([][]K, len(components))
+	for i, component := range components {
+		result[i] = keys(component)
+	}
+	return result
+}
+
+// Cycles returns one cycle for every strongly connected component which has a cycle, ordered by the first vertex
+// insertion. Each cycle is the shortest path from the first inserted vertex of the component back to itself,
+// Ex: [a b c a] for a -> b -> c -> a
+func (g *DirectedGraph[K, V]) Cycles() [][]K {
+	var cycles [][]*node[K, V]
+	for _, component := range g.components() {
+		if len(component) == 1 && !g.hasEdge(component[0], component[0]) {
+			continue
+		}
+		cycles = append(cycles, componentCycle(component))
+	}
+
+	sort.Slice(cycles, func(i, j int) bool {
+		return cycles[i][0].index < cycles[j][0].index
+	})
+
+	result := make([][]K, len(cycles))
+	for i, cycle := range cycles {
+		result[i] = keys(cycle)
+	}
+	return result
+}
+
+func (g *DirectedGraph[K, V]) components() [][]*node[K, V] {
+	t := tarjan[K, V]{
+		index:   make(map[*node[K, V]]int, len(g.order)),
+		lowLink: make(map[*node[K, V]]int, len(g.order)),
+		onStack: make(map[*node[K, V]]bool, len(g.order)),
+	}
+	for _, n := range g.order {
+		if _, ok := t.index[n]; !ok {
+			t.strongConnect(n)
+		}
+	}
+	return t.components
+}
+
+// componentCycle does bfs from the first inserted vertex of the component over the component edges
+// until it gets back to the vertex
+func componentCycle[K comparable, V any](component []*node[K, V]) []*node[K, V] {
+	inComponent := make(map[*node[K, V]]bool, len(component))
+	start := component[0]
+	for _, n := range component {
+		inComponent[n] = true
+		if n.index < start.index {
+			start = n
+		}
+	}
+
+	parent := map[*node[K, V]]*node[K, V]{start: nil}
+	queue := []*node[K, V]{start}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, to := range n.outgoing {
+			if to == start {
+				// walk back to the start and reverse, so the path starts and ends with start
+				cycle := []*node[K, V]{start}
+				for u := n; u != nil; u = parent[u] {
+					cycle = append(cycle, u)
+				}
+				for i, j := 0, len(cycle)-1; i < j; i, j = i+1, j-1 {
+					cycle[i], cycle[j] = cycle[j], cycle[i]
+				}
+				return cycle
+			}
+			if _, seen := parent[to]; !seen && inComponent[to] {
+				parent[to] = n
+				queue = append(queue, to)
+			}
+		}
+	}
+	// every strongly connected component with cycle has path back to start
+	return nil
+}
+
+// tarjan keeps the state of Tarjan's strongly connected components algorithm
+type tarjan[K comparable, V any] struct {
+	counter    int
+	index      map[*node[K, V]]int
+	lowLink    map[*node[K, V]]int
+	onStack    map[*node[K, V]]bool
+	stack      []*node[K, V]
+	components [][]*node[K, V]
+}
+
+func (t *tarjan[K, V]) strongConnect(n *node[K, V]) {
+	t.index[n] = t.counter
+	t.lowLink[n] = t.counter
+	t.counter++
+	t.stack = append(t.stack, n)
+	t.onStack[n] = true
+
+	for _, to := range n.outgoing {
+		if _, visited := t.index[to]; !visited {
+			t.strongConnect(to)
+			if t.lowLink[to] < t.lowLink[n] {
+				t.lowLink[n] = t.lowLink[to]
+			}
+		} else if t.onStack[to] && t.index[to] < t.lowLink[n] {
+			t.lowLink[n] = t.index[to]
+		}
+	}
+
+	if t.lowLink[n] != t.index[n] {
+		return
+	}
+
+	// n is root of component, everything above it on the stack belongs to the component
+	var component []*node[K, V]
+	for {
+		last := len(t.stack) - 1
+		u := t.stack[last]
+		t.stack = t.stack[:last]
+		t.onStack[u] = false
+		component = append(component, u)
+		if u == n {
+			break
+		}
+	}
+	t.components = append(t.components, component)
+}
@@ -0,0 +1,42 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CycleError is returned when the graph has cycles. Cycles holds one cycle per strongly connected component
+// as ordered path of the formatted vertex keys which starts and ends with the same vertex.
+// It matches GraphCycleErr with errors.Is
+type CycleError struct {
+	Cycles [][]string
+}
+
+func newCycleError[K comparable](cycles [][]K) *CycleError {
+	e := CycleError{Cycles: make([][]string, len(cycles))}
+	for i, cycle := range cycles {
+		e.Cycles[i] = make([]string, len(cycle))
+		for j, key := range cycle {
+			e.Cycles[i][j] = fmt.Sprint(key)
+		}
+	}
+	return &e
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("%s: %s", GraphCycleErr, strings.Join(e.Paths(), "; "))
+}
+
+// Is makes errors.Is(err, GraphCycleErr) work for CycleError
+func (e *CycleError) Is(target error) bool {
+	return target == GraphCycleErr
+}
+
+// Paths returns the cycles formatted as "a -> b -> c -> a"
+func (e *CycleError) Paths() []string {
+	paths := make([]string, len(e.Cycles))
+	for i, cycle := range e.Cycles {
+		paths[i] = strings.Join(cycle, " -> ")
+	}
+	return paths
+}
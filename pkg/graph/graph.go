@@ -9,108 +9,263 @@ var (
 	VertexNotFoundErr     = errors.New("vertex not found")
 	GraphCycleErr         = errors.New("there is cycle in the graph")
 	VertexIsNotDefinedErr = errors.New("vertex is not defined")
+	DuplicateVertexErr    = errors.New("vertex already exists")
 )
 
-// NewGraph should be used to initialize the internal structures
+// New should be used to initialize the internal structures
 // verticesNum is used to improve memory allocation for the vertices
-func NewGraph(verticesNum int) *DirectedGraph {
-	g := DirectedGraph{}
-	g.Vertices = make(map[string]*Vertex, verticesNum)
-	g.Edges = make(map[string]*Edge)
+func New[K comparable, V any](verticesNum int) *DirectedGraph[K, V] {
+	g := DirectedGraph[K, V]{}
+	g.nodes = make(map[K]*node[K, V], verticesNum)
+	g.order = make([]*node[K, V], 0, verticesNum)
+	g.edges = make(map[edge[K, V]]struct{})
 	return &g
 }
 
-type DirectedGraph struct {
-	Vertices map[string]*Vertex
-	Edges    map[string]*Edge
+// DirectedGraph is directed graph whose vertices are identified by key K and carry payload V
+// Every vertex keeps its outgoing and incoming adjacency lists, so every traversal visits
+// each vertex and each edge exactly once - O(n + e)
+type DirectedGraph[K comparable, V any] struct {
+	nodes map[K]*node[K, V]
+	// order keeps the vertices in insertion order, so traversals do not depend on map iteration
+	order []*node[K, V]
+	// edges is used to ignore duplicated edges
+	edges map[edge[K, V]]struct{}
 }
 
-type Vertex struct {
-	Name string
+// Edge is directed edge between two vertex keys
+type Edge[K comparable] struct {
+	From, To K
 }
 
-type Edge struct {
-	From, To *Vertex
+type node[K comparable, V any] struct {
+	key   K
+	value V
+	// index is the insertion position of the vertex in the graph
+	index int
+	// outgoing and incoming are the adjacency lists keyed by vertex identity
+	outgoing []*node[K, V]
+	incoming []*node[K, V]
 }
 
-// TopologicalSort is doing topological sort and returns GraphCycleErr if cycle appears
-func (g *DirectedGraph) TopologicalSort() ([]string, error) {
-	var sortedTasks []string
-	visited := make(map[string]bool)
-	processing := make(map[string]bool)
+type edge[K comparable, V any] struct {
+	from, to *node[K, V]
+}
 
-	for _, v := range g.Vertices {
-		if !visited[v.Name] {
-			err := g.processTask(v, &sortedTasks, visited, processing)
-			if err != nil {
-				return nil, err
-			}
-		}
+// AddVertex adds vertex with the given key and payload and returns DuplicateVertexErr if the key already exists
+// The existing vertex is kept as replacing it would orphan its adjacency lists
+func (g *DirectedGraph[K, V]) AddVertex(key K, value V) error {
+	if _, ok := g.nodes[key]; ok {
+		return fmt.Errorf("%w, Vertex: %v", DuplicateVertexErr, key)
 	}
-	return sortedTasks, nil
+	n := node[K, V]{key: key, value: value, index: len(g.order)}
+	g.nodes[key] = &n
+	g.order = append(g.order, &n)
+	return nil
 }
 
-// ProcessTask is recursive function for building doing bfs and ordering vertices
-// returns GraphCycleErr if cycle appears
-func (g *DirectedGraph) processTask(v *Vertex, sortedTasks *[]string, visited map[string]bool, processing map[string]bool) error {
-	processing[v.Name] = true
-	for _, edge := range g.Edges {
-		if edge.From.Name == v.Name {
-			if b := processing[edge.To.Name]; b {
-				return fmt.Errorf("%w. Cycle vertex %s", GraphCycleErr, edge.To.Name)
-			}
+// Vertex retrieves the payload of a vertex by key and returns VertexNotFoundErr
+func (g *DirectedGraph[K, V]) Vertex(key K) (V, error) {
+	n, err := g.node(key)
+	if err != nil {
+		var empty V
+		return empty, err
+	}
+	return n.value, nil
+}
 
-			if !visited[edge.To.Name] {
-				if err := g.processTask(edge.To, sortedTasks, visited, processing); err != nil {
-					return err
-				}
-			}
-		}
+// HasVertex reports whether vertex with the key exists
+func (g *DirectedGraph[K, V]) HasVertex(key K) bool {
+	_, ok := g.nodes[key]
+	return ok
+}
+
+// Len returns the number of vertices
+func (g *DirectedGraph[K, V]) Len() int {
+	return len(g.order)
+}
+
+// Keys returns the vertex keys in insertion order
+func (g *DirectedGraph[K, V]) Keys() []K {
+	return keys(g.order)
+}
+
+// AddEdge add edge and returns VertexNotFoundErr
+func (g *DirectedGraph[K, V]) AddEdge(from, to K) error {
+	fromNode, err := g.node(from)
+	if err != nil {
+		return err
 	}
 
-	visited[v.Name] = true
-	processing[v.Name] = false
+	toNode, err := g.node(to)
+	if err != nil {
+		return err
+	}
 
-	*sortedTasks = append(*sortedTasks, v.Name)
+	e := edge[K, V]{from: fromNode, to: toNode}
+	if _, ok := g.edges[e]; ok {
+		return nil
+	}
+	g.edges[e] = struct{}{}
+	fromNode.outgoing = append(fromNode.outgoing, toNode)
+	toNode.incoming = append(toNode.incoming, fromNode)
 	return nil
 }
 
-// Vertex retrieves a vertex by name and returns VertexNotFoundErr
-func (g *DirectedGraph) Vertex(name string) (*Vertex, error) {
-	if _, ok := g.Vertices[name]; !ok {
-		return nil, fmt.Errorf("%w, Vertex: %s", VertexNotFoundErr, name)
+// Edges returns all edges grouped by their source vertex in insertion order
+func (g *DirectedGraph[K, V]) Edges() []Edge[K] {
+	edges := make([]Edge[K], 0, len(g.edges))
+	for _, from := range g.order {
+		for _, to := range from.outgoing {
+			edges = append(edges, Edge[K]{From: from.key, To: to.key})
+		}
 	}
-	return g.Vertices[name], nil
+	return edges
 }
 
-func (g *DirectedGraph) AddVertex(name string) {
-	v := Vertex{Name: name}
-	g.Vertices[name] = &v
+// Outgoing returns the keys of the vertices which key points to
+func (g *DirectedGraph[K, V]) Outgoing(key K) ([]K, error) {
+	n, err := g.node(key)
+	if err != nil {
+		return nil, err
+	}
+	return keys(n.outgoing), nil
 }
 
-// AddEdge add edge and returns VertexNotFoundErr
-func (g *DirectedGraph) AddEdge(from, to *Vertex) error {
-	if from == nil || to == nil {
-		return VertexIsNotDefinedErr
+// Incoming returns the keys of the vertices which point to key
+func (g *DirectedGraph[K, V]) Incoming(key K) ([]K, error) {
+	n, err := g.node(key)
+	if err != nil {
+		return nil, err
 	}
+	return keys(n.incoming), nil
+}
 
-	if err := g.validateVertexExistence(from); err != nil {
+// Traverse does bfs over the outgoing edges starting from start and calls visit for every reachable vertex
+// including start. Traversal stops when visit returns false. Returns VertexNotFoundErr
+func (g *DirectedGraph[K, V]) Traverse(start K, visit func(key K, value V) bool) error {
+	n, err := g.node(start)
+	if err != nil {
 		return err
 	}
 
-	if err := g.validateVertexExistence(to); err != nil {
-		return err
+	seen := map[*node[K, V]]bool{n: true}
+	queue := []*node[K, V]{n}
+	for len(queue) > 0 {
+		n = queue[0]
+		queue = queue[1:]
+		if !visit(n.key, n.value) {
+			return nil
+		}
+		for _, to := range n.outgoing {
+			if !seen[to] {
+				seen[to] = true
+				queue = append(queue, to)
+			}
+		}
 	}
-
-	edge := Edge{From: from, To: to}
-	edgeName := fmt.Sprintf("%s-%s", from.Name, to.Name)
-	g.Edges[edgeName] = &edge
 	return nil
 }
 
-func (g *DirectedGraph) validateVertexExistence(v *Vertex) error {
-	if _, ok := g.Vertices[v.Name]; !ok {
-		return fmt.Errorf("%w, Name: %s ", VertexNotFoundErr, v.Name)
+// Descendants returns the keys of all vertices reachable from keys over the outgoing edges in insertion order
+// The passed keys are included only when they are reachable from another passed key or through cycle
+// Returns VertexNotFoundErr
+func (g *DirectedGraph[K, V]) Descendants(keys ...K) ([]K, error) {
+	return g.reachable(keys, func(n *node[K, V]) []*node[K, V] { return n.outgoing })
+}
+
+// Ancestors returns the keys of all vertices from which keys are reachable over the outgoing edges in insertion order
+// The passed keys are included only when they are reachable from another passed key or through cycle
+// Returns VertexNotFoundErr
+func (g *DirectedGraph[K, V]) Ancestors(keys ...K) ([]K, error) {
+	return g.reachable(keys, func(n *node[K, V]) []*node[K, V] { return n.incoming })
+}
+
+// Subgraph returns new graph with the vertices of keys and the edges between them
+// The insertion order of the vertices and the edges is preserved. Returns VertexNotFoundErr
+func (g *DirectedGraph[K, V]) Subgraph(keys ...K) (*DirectedGraph[K, V], error) {
+	selected := make(map[*node[K, V]]bool, len(keys))
+	for _, key := range keys {
+		n, err := g.node(key)
+		if err != nil {
+			return nil, err
+		}
+		selected[n] = true
 	}
-	return nil
+
+	sub := New[K, V](len(selected))
+	for _, n := range g.order {
+		if !selected[n] {
+			continue
+		}
+		if err := sub.AddVertex(n.key, n.value); err != nil {
+			return nil, err
+		}
+	}
+	for _, from := range g.order {
+		if !selected[from] {
+			continue
+		}
+		for _, to := range from.outgoing {
+			if selected[to] {
+				if err := sub.AddEdge(from.key, to.key); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return sub, nil
+}
+
+// reachable does bfs from all start keys over the adjacent vertices
+func (g *DirectedGraph[K, V]) reachable(start []K, adjacent func(n *node[K, V]) []*node[K, V]) ([]K, error) {
+	queue := make([]*node[K, V], 0, len(start))
+	for _, key := range start {
+		n, err := g.node(key)
+		if err != nil {
+			return nil, err
+		}
+		queue = append(queue, n)
+	}
+
+	reached := make(map[*node[K, V]]bool)
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, to := range adjacent(n) {
+			if !reached[to] {
+				reached[to] = true
+				queue = append(queue, to)
+			}
+		}
+	}
+
+	result := make([]K, 0, len(reached))
+	for _, n := range g.order {
+		if reached[n] {
+			result = append(result, n.key)
+		}
+	}
+	return result, nil
+}
+
+func (g *DirectedGraph[K, V]) node(key K) (*node[K, V], error) {
+	n, ok := g.nodes[key]
+	if !ok {
+		return nil, fmt.Errorf("%w, Vertex: %v", VertexNotFoundErr, key)
+	}
+	return n, nil
+}
+
+func (g *DirectedGraph[K, V]) hasEdge(from, to *node[K, V]) bool {
+	_, ok := g.edges[edge[K, V]{from: from, to: to}]
+	return ok
+}
+
+func keys[K comparable, V any](nodes []*node[K, V]) []K {
+	result := make([]K, len(nodes))
+	for i, n := range nodes {
+		result[i] = n.key
+	}
+	return result
 }
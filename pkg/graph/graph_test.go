@@ -1,6 +1,7 @@
 package graph
 
 import (
+	"fmt"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -8,19 +9,19 @@ import (
 
 var testTopologicalSort = []struct {
 	name                string
-	Vertices            []*Vertex
-	Edges               []*Edge
+	Vertices            []string
+	Edges               []Edge[string]
 	hasError            bool
 	expectedError       error
 	expectedSortedArray []string
 }{
 	{
 		"Test with four Vertices and three Edges which are connected",
-		[]*Vertex{{Name: "v1"}, {Name: "v2"}, {Name: "v3"}, {Name: "v4"}},
-		[]*Edge{
-			{From: &Vertex{Name: "v1"}, To: &Vertex{Name: "v2"}},
-			{From: &Vertex{Name: "v2"}, To: &Vertex{Name: "v3"}},
-			{From: &Vertex{Name: "v3"}, To: &Vertex{Name: "v4"}},
+		[]string{"v1", "v2", "v3", "v4"},
+		[]Edge[string]{
+			{From: "v1", To: "v2"},
+			{From: "v2", To: "v3"},
+			{From: "v3", To: "v4"},
 		},
 		false,
 		nil,
@@ -28,10 +29,10 @@ var testTopologicalSort = []struct {
 	},
 	{
 		"Test with cycle",
-		[]*Vertex{{Name: "v1"}, {Name: "v2"}},
-		[]*Edge{
-			{From: &Vertex{Name: "v1"}, To: &Vertex{Name: "v2"}},
-			{From: &Vertex{Name: "v2"}, To: &Vertex{Name: "v1"}},
+		[]string{"v1", "v2"},
+		[]Edge[string]{
+			{From: "v1", To: "v2"},
+			{From: "v2", To: "v1"},
 		},
 		true,
 		GraphCycleErr,
@@ -55,10 +56,112 @@ func TestTopologicalSort(t *testing.T) {
 	}
 }
 
-func initNewTestingGraph(t *testing.T, Vertices []*Vertex, Edges []*Edge) *DirectedGraph {
-	g := NewGraph(2)
-	for _, v := range Vertices {
-		g.AddVertex(v.Name)
+var testStableTopologicalSort = []struct {
+	name                string
+	Vertices            []string
+	Edges               []Edge[string]
+	order               SortOrder
+	hasError            bool
+	expectedError       error
+	expectedSortedArray []string
+}{
+	{
+		"Test without Edges in input order should keep insertion order",
+		[]string{"v3", "v1", "v2"},
+		nil,
+		InputOrder,
+		false,
+		nil,
+		[]string{"v3", "v1", "v2"},
+	},
+	{
+		"Test without Edges in lexical order should order by name",
+		[]string{"v3", "v1", "v2"},
+		nil,
+		LexicalOrder,
+		false,
+		nil,
+		[]string{"v1", "v2", "v3"},
+	},
+	{
+		"Test with diamond in input order should break ties by insertion order",
+		[]string{"d", "c", "b", "a"},
+		[]Edge[string]{
+			{From: "d", To: "b"},
+			{From: "d", To: "c"},
+			{From: "b", To: "a"},
+			{From: "c", To: "a"},
+		},
+		InputOrder,
+		false,
+		nil,
+		[]string{"a", "c", "b", "d"},
+	},
+	{
+		"Test with diamond in lexical order should break ties by name",
+		[]string{"d", "c", "b", "a"},
+		[]Edge[string]{
+			{From: "d", To: "b"},
+			{From: "d", To: "c"},
+			{From: "b", To: "a"},
+			{From: "c", To: "a"},
+		},
+		LexicalOrder,
+		false,
+		nil,
+		[]string{"a", "b", "c", "d"},
+	},
+	{
+		"Test with cycle",
+		[]string{"v1", "v2", "v3"},
+		[]Edge[string]{
+			{From: "v1", To: "v2"},
+			{From: "v2", To: "v1"},
+		},
+		InputOrder,
+		true,
+		GraphCycleErr,
+		nil,
+	},
+	{
+		"Test with self cycle",
+		[]string{"v1"},
+		[]Edge[string]{
+			{From: "v1", To: "v1"},
+		},
+		LexicalOrder,
+		true,
+		GraphCycleErr,
+		nil,
+	},
+}
+
+func TestStableTopologicalSort(t *testing.T) {
+	for _, tt := range testStableTopologicalSort {
+		t.Run(tt.name, func(t *testing.T) {
+			g := initNewTestingGraph(t, tt.Vertices, tt.Edges)
+			for i := 0; i < 3; i++ {
+				arr, err := g.StableTopologicalSort(tt.order.less())
+				if tt.hasError {
+					assert.NotNil(t, err)
+					assert.True(t, errors.Is(err, tt.expectedError))
+					return
+				}
+				assert.Nil(t, err)
+				assert.Equal(t, tt.expectedSortedArray, arr)
+			}
+		})
+	}
+}
+
+// initNewTestingGraph creates graph where the payload of every vertex is its index
+func initNewTestingGraph(t *testing.T, Vertices []string, Edges []Edge[string]) *DirectedGraph[string, int] {
+	g := New[string, int](len(Vertices))
+	for i, v := range Vertices {
+		if err := g.AddVertex(v, i); err != nil {
+			t.Fatal("Adding vertex failed with", err)
+			return nil
+		}
 	}
 
 	for _, e := range Edges {
@@ -70,21 +173,21 @@ func initNewTestingGraph(t *testing.T, Vertices []*Vertex, Edges []*Edge) *Direc
 	return g
 }
 
-var testProcessTask = []struct {
+var testProcessNode = []struct {
 	name                string
-	Vertices            []*Vertex
-	Edges               []*Edge
+	Vertices            []string
+	Edges               []Edge[string]
 	hasError            bool
 	expectedError       error
 	expectedSortedArray []string
 }{
 	{
 		"Test with four Vertices and three Edges which are connected starting from v1",
-		[]*Vertex{{Name: "v1"}, {Name: "v2"}, {Name: "v3"}, {Name: "v4"}},
-		[]*Edge{
-			{From: &Vertex{Name: "v1"}, To: &Vertex{Name: "v2"}},
-			{From: &Vertex{Name: "v2"}, To: &Vertex{Name: "v3"}},
-			{From: &Vertex{Name: "v3"}, To: &Vertex{Name: "v4"}},
+		[]string{"v1", "v2", "v3", "v4"},
+		[]Edge[string]{
+			{From: "v1", To: "v2"},
+			{From: "v2", To: "v3"},
+			{From: "v3", To: "v4"},
 		},
 		false,
 		nil,
@@ -92,11 +195,11 @@ var testProcessTask = []struct {
 	},
 	{
 		"Test with cycle starting from v1",
-		[]*Vertex{{Name: "v1"}, {Name: "v2"}, {Name: "v3"}},
-		[]*Edge{
-			{From: &Vertex{Name: "v1"}, To: &Vertex{Name: "v2"}},
-			{From: &Vertex{Name: "v2"}, To: &Vertex{Name: "v3"}},
-			{From: &Vertex{Name: "v3"}, To: &Vertex{Name: "v1"}},
+		[]string{"v1", "v2", "v3"},
+		[]Edge[string]{
+			{From: "v1", To: "v2"},
+			{From: "v2", To: "v3"},
+			{From: "v3", To: "v1"},
 		},
 		true,
 		GraphCycleErr,
@@ -104,35 +207,38 @@ var testProcessTask = []struct {
 	},
 }
 
-func TestProcessTask(t *testing.T) {
-	for _, tt := range testProcessTask {
+func TestProcessNode(t *testing.T) {
+	for _, tt := range testProcessNode {
 		t.Run(tt.name, func(t *testing.T) {
 			g := initNewTestingGraph(t, tt.Vertices, tt.Edges)
-			sortedTasks := make([]string, 0)
-			err := g.processTask(&Vertex{Name: "v1"}, &sortedTasks, make(map[string]bool), make(map[string]bool))
+			sorted := make([]string, 0)
+			err := g.processNode(g.nodes["v1"], &sorted, make(map[*node[string, int]]bool), make(map[*node[string, int]]bool))
 			if tt.hasError {
 				assert.NotNil(t, err)
 				assert.True(t, errors.Is(err, tt.expectedError))
 				return
 			}
 			assert.Nil(t, err)
-			assert.Equal(t, tt.expectedSortedArray, sortedTasks)
+			assert.Equal(t, tt.expectedSortedArray, sorted)
 		})
 	}
 }
 
 func TestVertex(t *testing.T) {
-	// Create a DAG with three Vertices.
-	g := NewGraph(2)
-	g.AddVertex("v1")
-	g.AddVertex("v2")
+	type payload struct {
+		command string
+	}
+
+	g := New[string, payload](2)
+	g.AddVertex("v1", payload{command: "c1"})
+	g.AddVertex("v2", payload{command: "c2"})
 
-	vertex, err := g.Vertex("v1")
+	value, err := g.Vertex("v1")
 	assert.Nil(t, err)
-	assert.Equal(t, "v1", vertex.Name)
-	vertex, err = g.Vertex("v2")
+	assert.Equal(t, payload{command: "c1"}, value)
+	value, err = g.Vertex("v2")
 	assert.Nil(t, err)
-	assert.Equal(t, "v2", vertex.Name)
+	assert.Equal(t, payload{command: "c2"}, value)
 
 	_, err = g.Vertex("v3")
 	assert.NotNil(t, err)
@@ -140,53 +246,458 @@ func TestVertex(t *testing.T) {
 }
 
 func TestAddVertex(t *testing.T) {
-	g := NewGraph(2)
-	assert.Len(t, g.Vertices, 0)
+	g := New[int, string](2)
+	assert.Equal(t, 0, g.Len())
+
+	g.AddVertex(2, "v2")
+	assert.Equal(t, 1, g.Len())
+	assert.True(t, g.HasVertex(2))
+
+	g.AddVertex(1, "v1")
+	assert.Equal(t, 2, g.Len())
+	assert.True(t, g.HasVertex(1))
+	assert.Equal(t, []int{2, 1}, g.Keys())
+}
 
-	g.AddVertex("v1")
-	assert.Len(t, g.Vertices, 1)
-	vertex, ok := g.Vertices["v1"]
-	assert.True(t, ok)
-	assert.Equal(t, "v1", vertex.Name)
+func TestAddVertexShouldNotReplaceExistingVertex(t *testing.T) {
+	g := New[string, int](2)
+	assert.Nil(t, g.AddVertex("v1", 1))
+	err := g.AddVertex("v1", 2)
+	assert.True(t, errors.Is(err, DuplicateVertexErr))
 
-	g.AddVertex("v2")
-	assert.Len(t, g.Vertices, 2)
-	vertex, ok = g.Vertices["v2"]
-	assert.True(t, ok)
-	assert.Equal(t, "v2", vertex.Name)
+	assert.Equal(t, 1, g.Len())
+	value, err := g.Vertex("v1")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, value)
 }
 
 var testAddEdge = []struct {
 	name          string
-	from          *Vertex
-	to            *Vertex
+	from          string
+	to            string
 	hasError      bool
 	expectedError error
 }{
-	{"Test add edge with nil from vertex should return error", nil, &Vertex{Name: "to"}, true, VertexIsNotDefinedErr},
-	{"Test add edge with nil to vertex should return error", &Vertex{Name: "from"}, nil, true, VertexIsNotDefinedErr},
-	{"Test add edge with existing Vertices should add Vertices", &Vertex{Name: "from"}, &Vertex{Name: "to"}, false, nil},
-	{"Test add edge with non existing Vertices should return", &Vertex{Name: "non"}, &Vertex{Name: "exist"}, true, VertexNotFoundErr},
+	{"Test add edge with non existing from vertex should return error", "non", "to", true, VertexNotFoundErr},
+	{"Test add edge with non existing to vertex should return error", "from", "exist", true, VertexNotFoundErr},
+	{"Test add edge with existing Vertices should add edge", "from", "to", false, nil},
 }
 
 func TestAddEdge(t *testing.T) {
-	g := NewGraph(2)
-	g.AddVertex("from")
-	g.AddVertex("to")
-
 	for _, tt := range testAddEdge {
 		t.Run(tt.name, func(t *testing.T) {
+			g := initNewTestingGraph(t, []string{"from", "to"}, nil)
 			err := g.AddEdge(tt.from, tt.to)
 			if tt.hasError {
 				assert.NotNil(t, err)
 				assert.True(t, errors.Is(err, tt.expectedError))
+				assert.Empty(t, g.Edges())
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, []Edge[string]{{From: "from", To: "to"}}, g.Edges())
+		})
+	}
+}
+
+func TestAddEdgeShouldKeepAdjacencyLists(t *testing.T) {
+	g := initNewTestingGraph(t, []string{"v1", "v2", "v3"}, []Edge[string]{
+		{From: "v1", To: "v2"},
+		{From: "v1", To: "v3"},
+		{From: "v3", To: "v2"},
+		// duplicated edge should be ignored
+		{From: "v1", To: "v2"},
+	})
+
+	outgoing, err := g.Outgoing("v1")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"v2", "v3"}, outgoing)
+
+	incoming, err := g.Incoming("v2")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"v1", "v3"}, incoming)
+
+	outgoing, err = g.Outgoing("v2")
+	assert.Nil(t, err)
+	assert.Empty(t, outgoing)
+
+	_, err = g.Incoming("v4")
+	assert.True(t, errors.Is(err, VertexNotFoundErr))
+	assert.Len(t, g.Edges(), 3)
+}
+
+var testTraverse = []struct {
+	name          string
+	start         string
+	limit         int
+	hasError      bool
+	expectedError error
+	expectedKeys  []string
+}{
+	{"Test traverse should visit every reachable vertex in bfs order", "v1", 10, false, nil, []string{"v1", "v2", "v3", "v4"}},
+	{"Test traverse should not visit vertices which are not reachable", "v3", 10, false, nil, []string{"v3", "v4"}},
+	{"Test traverse should stop when visit returns false", "v1", 2, false, nil, []string{"v1", "v2"}},
+	{"Test traverse from non existing vertex should return error", "v6", 10, true, VertexNotFoundErr, nil},
+}
+
+func TestTraverse(t *testing.T) {
+	g := initNewTestingGraph(t, []string{"v1", "v2", "v3", "v4", "v5"}, []Edge[string]{
+		{From: "v1", To: "v2"},
+		{From: "v1", To: "v3"},
+		{From: "v2", To: "v4"},
+		{From: "v3", To: "v4"},
+		{From: "v5", To: "v1"},
+	})
+
+	for _, tt := range testTraverse {
+		t.Run(tt.name, func(t *testing.T) {
+			var visited []string
+			err := g.Traverse(tt.start, func(key string, value int) bool {
+				visited = append(visited, key)
+				return len(visited) < tt.limit
+			})
+			if tt.hasError {
+				assert.NotNil(t, err)
+				assert.True(t, errors.Is(err, tt.expectedError))
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expectedKeys, visited)
+		})
+	}
+}
+
+// chainGraph builds v0 -> v1 -> ... -> vn, the deepest possible dfs
+func chainGraph(b *testing.B, n int) *DirectedGraph[int, struct{}] {
+	g := New[int, struct{}](n)
+	for i := 0; i < n; i++ {
+		g.AddVertex(i, struct{}{})
+	}
+	for i := 1; i < n; i++ {
+		if err := g.AddEdge(i-1, i); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return g
+}
+
+// layeredGraph builds layers of width vertices where every vertex points to every vertex of the next layer
+func layeredGraph(b *testing.B, n, width int) *DirectedGraph[int, struct{}] {
+	g := New[int, struct{}](n)
+	for i := 0; i < n; i++ {
+		g.AddVertex(i, struct{}{})
+	}
+	for i := width; i < n; i++ {
+		layerStart := (i/width - 1) * width
+		for j := layerStart; j < layerStart+width; j++ {
+			if err := g.AddEdge(j, i); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	return g
+}
+
+// BenchmarkTopologicalSort should scale linearly with the number of vertices and edges
+func BenchmarkTopologicalSort(b *testing.B) {
+	for _, n := range []int{1000, 10000, 100000} {
+		b.Run(fmt.Sprintf("chain-%d", n), func(b *testing.B) {
+			g := chainGraph(b, n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := g.TopologicalSort(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("layered-%d", n), func(b *testing.B) {
+			g := layeredGraph(b, n, 4)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := g.TopologicalSort(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkStableTopologicalSort adds the log(n) heap factor on top of the linear traversal
+func BenchmarkStableTopologicalSort(b *testing.B) {
+	for _, n := range []int{1000, 10000, 100000} {
+		b.Run(fmt.Sprintf("layered-%d", n), func(b *testing.B) {
+			g := layeredGraph(b, n, 4)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := g.StableTopologicalSort(nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+var testCycles = []struct {
+	name           string
+	Vertices       []string
+	Edges          []Edge[string]
+	expectedCycles [][]string
+}{
+	{
+		"Test without cycle should return no cycles",
+		[]string{"v1", "v2"},
+		[]Edge[string]{
+			{From: "v1", To: "v2"},
+		},
+		[][]string{},
+	},
+	{
+		"Test with self cycle should return single vertex path",
+		[]string{"v1"},
+		[]Edge[string]{
+			{From: "v1", To: "v1"},
+		},
+		[][]string{{"v1", "v1"}},
+	},
+	{
+		"Test with two separate cycles should return both in insertion order",
+		[]string{"v1", "v2", "v3", "v4", "v5", "v6"},
+		[]Edge[string]{
+			{From: "v6", To: "v3"},
+			{From: "v3", To: "v4"},
+			{From: "v4", To: "v5"},
+			{From: "v5", To: "v3"},
+			{From: "v1", To: "v2"},
+			{From: "v2", To: "v1"},
+		},
+		[][]string{{"v1", "v2", "v1"}, {"v3", "v4", "v5", "v3"}},
+	},
+	{
+		"Test with component of several cycles should return the shortest one",
+		[]string{"a", "b", "c"},
+		[]Edge[string]{
+			{From: "a", To: "b"},
+			{From: "b", To: "c"},
+			{From: "c", To: "a"},
+			{From: "b", To: "a"},
+		},
+		[][]string{{"a", "b", "a"}},
+	},
+}
+
+func TestCycles(t *testing.T) {
+	for _, tt := range testCycles {
+		t.Run(tt.name, func(t *testing.T) {
+			g := initNewTestingGraph(t, tt.Vertices, tt.Edges)
+			assert.Equal(t, tt.expectedCycles, g.Cycles())
+		})
+	}
+}
+
+func TestStronglyConnectedComponents(t *testing.T) {
+	g := initNewTestingGraph(t,
+		[]string{"v1", "v2", "v3"},
+		[]Edge[string]{
+			{From: "v1", To: "v2"},
+			{From: "v2", To: "v1"},
+			{From: "v2", To: "v3"},
+		},
+	)
+
+	components := g.StronglyConnectedComponents()
+	assert.Len(t, components, 2)
+	// reverse topological order - v3 is required by the v1, v2 component
+	assert.Equal(t, []string{"v3"}, components[0])
+	assert.ElementsMatch(t, []string{"v1", "v2"}, components[1])
+}
+
+func TestSortShouldReturnCycleError(t *testing.T) {
+	g := initNewTestingGraph(t,
+		[]string{"v1", "v2", "v3"},
+		[]Edge[string]{
+			{From: "v1", To: "v2"},
+			{From: "v2", To: "v3"},
+			{From: "v3", To: "v2"},
+		},
+	)
+
+	sorts := map[string]func() ([]string, error){
+		"dfs":  g.TopologicalSort,
+		"kahn": func() ([]string, error) { return g.StableTopologicalSort(nil) },
+	}
+	for name, sortFunc := range sorts {
+		t.Run(name, func(t *testing.T) {
+			_, err := sortFunc()
+			assert.True(t, errors.Is(err, GraphCycleErr))
+
+			var cycleErr *CycleError
+			assert.True(t, errors.As(err, &cycleErr))
+			assert.Equal(t, []string{"v2 -> v3 -> v2"}, cycleErr.Paths())
+			assert.Equal(t, "there is cycle in the graph: v2 -> v3 -> v2", err.Error())
+		})
+	}
+}
+
+var testLevels = []struct {
+	name           string
+	Vertices       []string
+	Edges          []Edge[string]
+	order          SortOrder
+	hasError       bool
+	expectedError  error
+	expectedLevels [][]string
+}{
+	{
+		"Test without Edges should return single level in insertion order",
+		[]string{"v3", "v1", "v2"},
+		nil,
+		InputOrder,
+		false,
+		nil,
+		[][]string{{"v3", "v1", "v2"}},
+	},
+	{
+		"Test with chain should return level per vertex",
+		[]string{"v1", "v2", "v3"},
+		[]Edge[string]{
+			{From: "v1", To: "v2"},
+			{From: "v2", To: "v3"},
+		},
+		InputOrder,
+		false,
+		nil,
+		[][]string{{"v3"}, {"v2"}, {"v1"}},
+	},
+	{
+		"Test should place every vertex in the earliest possible level",
+		[]string{"d", "c", "b", "a", "e"},
+		[]Edge[string]{
+			{From: "d", To: "b"},
+			{From: "d", To: "c"},
+			{From: "b", To: "a"},
+			{From: "c", To: "a"},
+			{From: "d", To: "e"},
+		},
+		InputOrder,
+		false,
+		nil,
+		[][]string{{"a", "e"}, {"c", "b"}, {"d"}},
+	},
+	{
+		"Test in lexical order should order every level by name",
+		[]string{"d", "c", "b", "a", "e"},
+		[]Edge[string]{
+			{From: "d", To: "b"},
+			{From: "d", To: "c"},
+			{From: "b", To: "a"},
+			{From: "c", To: "a"},
+			{From: "d", To: "e"},
+		},
+		LexicalOrder,
+		false,
+		nil,
+		[][]string{{"a", "e"}, {"b", "c"}, {"d"}},
+	},
+	{
+		"Test with cycle should return cycle error",
+		[]string{"v1", "v2", "v3"},
+		[]Edge[string]{
+			{From: "v1", To: "v2"},
+			{From: "v2", To: "v1"},
+		},
+		InputOrder,
+		true,
+		GraphCycleErr,
+		nil,
+	},
+}
+
+func TestLevels(t *testing.T) {
+	for _, tt := range testLevels {
+		t.Run(tt.name, func(t *testing.T) {
+			g := initNewTestingGraph(t, tt.Vertices, tt.Edges)
+			levels, err := g.Levels(tt.order.less())
+			if tt.hasError {
+				assert.NotNil(t, err)
+				assert.True(t, errors.Is(err, tt.expectedError))
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expectedLevels, levels)
+		})
+	}
+}
+
+// traversalGraph is v5 -> v1 -> v2 -> v4 and v1 -> v3 -> v4 with separate v6
+var traversalGraph = struct {
+	Vertices []string
+	Edges    []Edge[string]
+}{
+	[]string{"v1", "v2", "v3", "v4", "v5", "v6"},
+	[]Edge[string]{
+		{From: "v1", To: "v2"},
+		{From: "v1", To: "v3"},
+		{From: "v2", To: "v4"},
+		{From: "v3", To: "v4"},
+		{From: "v5", To: "v1"},
+	},
+}
+
+var testReachable = []struct {
+	name                string
+	keys                []string
+	hasError            bool
+	expectedError       error
+	expectedDescendants []string
+	expectedAncestors   []string
+}{
+	{"Test with root vertex should return all reachable vertices", []string{"v5"}, false, nil, []string{"v1", "v2", "v3", "v4"}, []string{}},
+	{"Test with middle vertex", []string{"v2"}, false, nil, []string{"v4"}, []string{"v1", "v5"}},
+	{"Test with multiple vertices should return the union", []string{"v2", "v3"}, false, nil, []string{"v4"}, []string{"v1", "v5"}},
+	{"Test with vertex reachable from another passed vertex should include it", []string{"v1", "v4"}, false, nil, []string{"v2", "v3", "v4"}, []string{"v1", "v2", "v3", "v5"}},
+	{"Test with separate vertex should return nothing", []string{"v6"}, false, nil, []string{}, []string{}},
+	{"Test with non existing vertex should return error", []string{"v1", "v7"}, true, VertexNotFoundErr, nil, nil},
+}
+
+func TestDescendantsAndAncestors(t *testing.T) {
+	g := initNewTestingGraph(t, traversalGraph.Vertices, traversalGraph.Edges)
+	for _, tt := range testReachable {
+		t.Run(tt.name, func(t *testing.T) {
+			descendants, err := g.Descendants(tt.keys...)
+			if tt.hasError {
+				assert.True(t, errors.Is(err, tt.expectedError))
 			} else {
 				assert.Nil(t, err)
-				edge, ok := g.Edges["from-to"]
-				assert.True(t, ok)
-				assert.Equal(t, "from", edge.From.Name)
-				assert.Equal(t, "to", edge.To.Name)
+				assert.Equal(t, tt.expectedDescendants, descendants)
 			}
+
+			ancestors, err := g.Ancestors(tt.keys...)
+			if tt.hasError {
+				assert.True(t, errors.Is(err, tt.expectedError))
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expectedAncestors, ancestors)
 		})
 	}
 }
+
+func TestSubgraph(t *testing.T) {
+	g := initNewTestingGraph(t, traversalGraph.Vertices, traversalGraph.Edges)
+
+	sub, err := g.Subgraph("v4", "v2", "v1", "v6")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"v1", "v2", "v4", "v6"}, sub.Keys())
+	assert.Equal(t, []Edge[string]{{From: "v1", To: "v2"}, {From: "v2", To: "v4"}}, sub.Edges())
+
+	value, err := sub.Vertex("v4")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, value)
+
+	// the original graph should not be changed
+	assert.Equal(t, 6, g.Len())
+	assert.Len(t, g.Edges(), 5)
+
+	_, err = g.Subgraph("v7")
+	assert.True(t, errors.Is(err, VertexNotFoundErr))
+}
@@ -0,0 +1,73 @@
+package graph
+
+// Vertex is the payload of NamedGraph vertices
+type Vertex struct {
+	Name string
+}
+
+// SortOrder defines how vertices which are ready at the same time are ordered by NamedGraph.StableTopologicalSort
+type SortOrder int
+
+const (
+	// InputOrder keeps the order in which the vertices have been added
+	InputOrder SortOrder = iota
+	// LexicalOrder orders the vertices by name
+	LexicalOrder
+)
+
+// NamedGraph is thin adapter over DirectedGraph for vertices identified by name
+// It keeps the *Vertex based API used by job.Graph
+type NamedGraph struct {
+	*DirectedGraph[string, *Vertex]
+}
+
+// NewGraph should be used to initialize the internal structures
+// verticesNum is used to improve memory allocation for the vertices
+func NewGraph(verticesNum int) *NamedGraph {
+	return &NamedGraph{DirectedGraph: New[string, *Vertex](verticesNum)}
+}
+
+// AddVertex adds vertex with the given name and returns DuplicateVertexErr if the name already exists
+func (g *NamedGraph) AddVertex(name string) error {
+	return g.DirectedGraph.AddVertex(name, &Vertex{Name: name})
+}
+
+// AddEdge add edge and returns VertexNotFoundErr
+// Vertices are resolved by name, so the edge always points to the vertices owned by the graph
+func (g *NamedGraph) AddEdge(from, to *Vertex) error {
+	if from == nil || to == nil {
+		return VertexIsNotDefinedErr
+	}
+	return g.DirectedGraph.AddEdge(from.Name, to.Name)
+}
+
+// Subgraph returns new NamedGraph with the vertices of names and the edges between them
+// Returns VertexNotFoundErr
+func (g *NamedGraph) Subgraph(names ...string) (*NamedGraph, error) {
+	sub, err := g.DirectedGraph.Subgraph(names...)
+	if err != nil {
+		return nil, err
+	}
+	return &NamedGraph{DirectedGraph: sub}, nil
+}
+
+// StableTopologicalSort is doing topological sort using Kahn's algorithm where ties are broken by SortOrder
+// Returns *CycleError if cycle appears
+func (g *NamedGraph) StableTopologicalSort(order SortOrder) ([]string, error) {
+	return g.DirectedGraph.StableTopologicalSort(order.less())
+}
+
+// Levels groups the vertices into ordered levels of independent vertices, vertices of a level are ordered by SortOrder
+// Returns *CycleError if cycle appears
+func (g *NamedGraph) Levels(order SortOrder) ([][]string, error) {
+	return g.DirectedGraph.Levels(order.less())
+}
+
+func (o SortOrder) less() func(a, b string) bool {
+	if o == LexicalOrder {
+		return func(a, b string) bool {
+			return a < b
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package graph
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var testNamedAddEdge = []struct {
+	name          string
+	from          *Vertex
+	to            *Vertex
+	hasError      bool
+	expectedError error
+}{
+	{"Test add edge with nil from vertex should return error", nil, &Vertex{Name: "to"}, true, VertexIsNotDefinedErr},
+	{"Test add edge with nil to vertex should return error", &Vertex{Name: "from"}, nil, true, VertexIsNotDefinedErr},
+	{"Test add edge with existing Vertices should add edge", &Vertex{Name: "from"}, &Vertex{Name: "to"}, false, nil},
+	{"Test add edge with non existing Vertices should return", &Vertex{Name: "non"}, &Vertex{Name: "exist"}, true, VertexNotFoundErr},
+}
+
+func TestNamedAddEdge(t *testing.T) {
+	for _, tt := range testNamedAddEdge {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGraph(2)
+			g.AddVertex("from")
+			g.AddVertex("to")
+
+			err := g.AddEdge(tt.from, tt.to)
+			if tt.hasError {
+				assert.NotNil(t, err)
+				assert.True(t, errors.Is(err, tt.expectedError))
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, []Edge[string]{{From: "from", To: "to"}}, g.Edges())
+		})
+	}
+}
+
+func TestNamedVertex(t *testing.T) {
+	g := NewGraph(2)
+	g.AddVertex("v1")
+
+	vertex, err := g.Vertex("v1")
+	assert.Nil(t, err)
+	assert.Equal(t, "v1", vertex.Name)
+
+	_, err = g.Vertex("v2")
+	assert.True(t, errors.Is(err, VertexNotFoundErr))
+}
+
+func TestNamedStableTopologicalSort(t *testing.T) {
+	g := NewGraph(3)
+	g.AddVertex("v3")
+	g.AddVertex("v1")
+	g.AddVertex("v2")
+
+	arr, err := g.StableTopologicalSort(InputOrder)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"v3", "v1", "v2"}, arr)
+
+	arr, err = g.StableTopologicalSort(LexicalOrder)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"v1", "v2", "v3"}, arr)
+}
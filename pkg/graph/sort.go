@@ -0,0 +1,158 @@
+package graph
+
+import (
+	"container/heap"
+	"github.com/pkg/errors"
+	"sort"
+)
+
+// TopologicalSort is doing topological sort and returns *CycleError if cycle appears
+// Every vertex is placed after all the vertices it points to
+func (g *DirectedGraph[K, V]) TopologicalSort() ([]K, error) {
+	sorted := make([]K, 0, len(g.order))
+	visited := make(map[*node[K, V]]bool, len(g.order))
+	processing := make(map[*node[K, V]]bool)
+
+	for _, n := range g.order {
+		if !visited[n] {
+			err := g.processNode(n, &sorted, visited, processing)
+			if errors.Is(err, GraphCycleErr) {
+				return nil, newCycleError(g.Cycles())
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return sorted, nil
+}
+
+// processNode is recursive function doing dfs over the outgoing edges and ordering vertices
+// returns GraphCycleErr if cycle appears
+func (g *DirectedGraph[K, V]) processNode(n *node[K, V], sorted *[]K, visited map[*node[K, V]]bool, processing map[*node[K, V]]bool) error {
+	processing[n] = true
+	for _, to := range n.outgoing {
+		if processing[to] {
+			return GraphCycleErr
+		}
+
+		if !visited[to] {
+			if err := g.processNode(to, sorted, visited, processing); err != nil {
+				return err
+			}
+		}
+	}
+
+	visited[n] = true
+	processing[n] = false
+
+	*sorted = append(*sorted, n.key)
+	return nil
+}
+
+// StableTopologicalSort is doing topological sort using Kahn's algorithm. Vertices which are ready at the same time
+// are ordered by less and by insertion order when less is nil or the keys are equal, so the result is the same
+// on every call. Returns *CycleError if cycle appears
+func (g *DirectedGraph[K, V]) StableTopologicalSort(less func(a, b K) bool) ([]K, error) {
+	ready := &nodeQueue[K, V]{less: less}
+	// pending holds the number of outgoing vertices which are not sorted yet
+	pending := make(map[*node[K, V]]int, len(g.order))
+	for _, n := range g.order {
+		pending[n] = len(n.outgoing)
+		if pending[n] == 0 {
+			ready.nodes = append(ready.nodes, n)
+		}
+	}
+	heap.Init(ready)
+
+	sorted := make([]K, 0, len(g.order))
+	for ready.Len() > 0 {
+		n := heap.Pop(ready).(*node[K, V])
+		sorted = append(sorted, n.key)
+		for _, from := range n.incoming {
+			pending[from]--
+			if pending[from] == 0 {
+				heap.Push(ready, from)
+			}
+		}
+	}
+
+	if len(sorted) != len(g.order) {
+		return nil, newCycleError(g.Cycles())
+	}
+	return sorted, nil
+}
+
+// Levels groups the vertices into ordered levels (antichains), where every vertex points only to vertices from
+// earlier levels, so the vertices of one level are independent and could be processed at the same time.
+// Every vertex is placed in the earliest possible level. Vertices of a level are ordered by less and by
+// insertion order when less is nil or the keys are equal. Returns *CycleError if cycle appears
+func (g *DirectedGraph[K, V]) Levels(less func(a, b K) bool) ([][]K, error) {
+	byOrder := nodeQueue[K, V]{less: less}
+	// pending holds the number of outgoing vertices which are not in a level yet
+	pending := make(map[*node[K, V]]int, len(g.order))
+	var level []*node[K, V]
+	for _, n := range g.order {
+		pending[n] = len(n.outgoing)
+		if pending[n] == 0 {
+			level = append(level, n)
+		}
+	}
+
+	var levels [][]K
+	leveled := 0
+	for len(level) > 0 {
+		byOrder.nodes = level
+		sort.Sort(&byOrder)
+		levels = append(levels, keys(level))
+		leveled += len(level)
+
+		var next []*node[K, V]
+		for _, n := range level {
+			for _, from := range n.incoming {
+				pending[from]--
+				if pending[from] == 0 {
+					next = append(next, from)
+				}
+			}
+		}
+		level = next
+	}
+
+	if leveled != len(g.order) {
+		return nil, newCycleError(g.Cycles())
+	}
+	return levels, nil
+}
+
+// nodeQueue is priority queue (heap.Interface) of vertices ordered by less and insertion order
+type nodeQueue[K comparable, V any] struct {
+	nodes []*node[K, V]
+	less  func(a, b K) bool
+}
+
+func (q *nodeQueue[K, V]) Len() int { return len(q.nodes) }
+
+func (q *nodeQueue[K, V]) Less(i, j int) bool {
+	a, b := q.nodes[i], q.nodes[j]
+	if q.less != nil {
+		if q.less(a.key, b.key) {
+			return true
+		}
+		if q.less(b.key, a.key) {
+			return false
+		}
+	}
+	return a.index < b.index
+}
+
+func (q *nodeQueue[K, V]) Swap(i, j int) { q.nodes[i], q.nodes[j] = q.nodes[j], q.nodes[i] }
+
+func (q *nodeQueue[K, V]) Push(x any) { q.nodes = append(q.nodes, x.(*node[K, V])) }
+
+func (q *nodeQueue[K, V]) Pop() any {
+	last := len(q.nodes) - 1
+	n := q.nodes[last]
+	q.nodes = q.nodes[:last]
+	return n
+}
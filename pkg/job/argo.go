@@ -0,0 +1,95 @@
+package job
+
+import (
+	"net/http"
+
+	"github.com/ivanspasov99/golang-api/pkg/config"
+)
+
+const (
+	argo = "argo"
+
+	// argoEntrypoint is the name of the DAG template, task templates never get it
+	argoEntrypoint = "job"
+)
+
+// argoWorkflow, argoTemplate, etc. are the used subset of Argo Workflows v1alpha1 types
+type argoWorkflow struct {
+	k8sObject `json:",inline"`
+	Spec      argoWorkflowSpec `json:"spec"`
+}
+
+type argoWorkflowSpec struct {
+	Entrypoint string         `json:"entrypoint"`
+	Templates  []argoTemplate `json:"templates"`
+}
+
+type argoTemplate struct {
+	Name     string         `json:"name"`
+	Metadata *k8sObjectMeta `json:"metadata,omitempty"`
+	DAG      *argoDAG       `json:"dag,omitempty"`
+	Script   *argoScript    `json:"script,omitempty"`
+}
+
+type argoDAG struct {
+	Tasks []argoDAGTask `json:"tasks"`
+}
+
+type argoDAGTask struct {
+	Name         string   `json:"name"`
+	Template     string   `json:"template"`
+	Dependencies []string `json:"dependencies,omitempty"`
+}
+
+type argoScript struct {
+	Image   string   `json:"image"`
+	Command []string `json:"command"`
+	Source  string   `json:"source"`
+}
+
+// writeArgo writes Argo Workflow with DAG template, every task is script template of the configured image
+// (see config.Config.Image) and its requires are the DAG task dependencies. Task names are sanitized into
+// DNS labels (see dnsLabelIDs), the original name is kept in the template metadata annotations
+func writeArgo(w http.ResponseWriter, p *Plan) error {
+	conf := config.AppConfig()
+	image := taskImage(conf)
+	ids := sanitizeIDs(commandNames(p.Commands), dnsLabelIDs, argoEntrypoint)
+	if err := setIDsHeader(w, ids); err != nil {
+		return err
+	}
+
+	dag := argoDAG{Tasks: make([]argoDAGTask, len(p.Commands))}
+	templates := make([]argoTemplate, 0, len(p.Commands)+1)
+	templates = append(templates, argoTemplate{Name: argoEntrypoint, DAG: &dag})
+	for i, c := range p.Commands {
+		dag.Tasks[i] = argoDAGTask{Name: ids[c.Name], Template: ids[c.Name], Dependencies: requiredIDs(c, ids)}
+		templates = append(templates, argoTemplate{
+			Name:     ids[c.Name],
+			Metadata: &k8sObjectMeta{Annotations: map[string]string{k8sTaskNameAnnotation: c.Name}},
+			Script:   &argoScript{Image: image, Command: []string{"/bin/sh"}, Source: c.Script},
+		})
+	}
+
+	workflow := argoWorkflow{
+		k8sObject: k8sObject{
+			APIVersion: "argoproj.io/v1alpha1",
+			Kind:       "Workflow",
+			Metadata: k8sObjectMeta{
+				GenerateName: argoEntrypoint + "-",
+				Namespace:    conf.K8s.Namespace,
+				Labels:       map[string]string{k8sManagedByLabel: k8sManagedBy},
+			},
+		},
+		Spec: argoWorkflowSpec{Entrypoint: argoEntrypoint, Templates: templates},
+	}
+	return writeYAML(w, "create with: kubectl create -f -", p.Dropped, workflow)
+}
+
+// requiredIDs returns the identifiers of the command requires
+func requiredIDs(c Command, ids map[string]string) []string {
+	required := make([]string, len(c.Requires))
+	for i, r := range c.Requires {
+		required[i] = ids[r]
+	}
+	return required
+}
@@ -0,0 +1,23 @@
+package job
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ivanspasov99/golang-api/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteArgo(t *testing.T) {
+	if err := config.InitConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	err := writeArgo(rr, &exportPlan)
+	assert.Nil(t, err)
+	assertGolden(t, "argo.golden", rr.Body.String())
+
+	err = writeArgo(ErrorResponseWriter{}, &Plan{})
+	assert.NotNil(t, err)
+}
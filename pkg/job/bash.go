@@ -0,0 +1,220 @@
+package job
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	bash         = "bash"
+	bashPlain    = "bash-plain"
+	bashParallel = "bash-parallel"
+
+	jobsQuery = "jobs"
+
+	// we could identify where bash is installed
+	// or use community dependency for generating bash script
+	bashHeader = "#!/usr/bin/env bash"
+
+	// bashPrelude defines the marker logging and the ERR trap of the hardened script
+	// markers are written to stderr, so the output of the commands is not changed. The trap is not inherited by the
+	// task subshells (no errtrace), so it reports the failing task once, when its subshell exits
+	bashPrelude = `__task=''
+__log() { printf '[%s] %s\n' "$(date -u +%Y-%m-%dT%H:%M:%SZ)" "$*" >&2; }
+trap '__code=$?; __log "task ${__task} failed with exit code ${__code}"; exit "${__code}"' ERR
+`
+
+	// bashRetryPrelude defines __retry which runs the task script until it succeeds, its exit code is not retried or
+	// the attempts run out. Every attempt is evaluated in subshell as the task without retry (see embedCommand), so it
+	// sees the variables and functions of the script. errexit is switched off only around the subshell, which turns it
+	// on again, as the strict mode does not stop the subshell whose exit code is checked. __retry should be called as
+	// plain statement. The arguments are the delays in seconds before every retry and the retried exit codes (empty
+	// is every non zero exit code), both space separated
+	bashRetryPrelude = `__retry() {
+	local delays=($1) retry_on=" $2 " attempt=1 code
+	local attempts=$((${#delays[@]} + 1))
+	while true; do
+		set +e
+		( set -e; eval "${__script}" ) </dev/null
+		code=$?
+		set -e
+		__log "task ${__task} attempt ${attempt}/${attempts} exited with code ${code}"
+		if [ "${code}" -eq 0 ]; then
+			return 0
+		fi
+		if [ "${attempt}" -ge "${attempts}" ] || { [ "${retry_on}" != "  " ] && [[ "${retry_on}" != *" ${code} "* ]]; }; then
+			return "${code}"
+		fi
+		__log "retrying task ${__task} in ${delays[attempt - 1]}s"
+		sleep "${delays[attempt - 1]}"
+		attempt=$((attempt + 1))
+	done
+}
+`
+
+	// bashParallelPrelude defines the helpers which start the tasks of a stage as background jobs and wait for them
+	// Every task runs in its own process group, so __stop terminates the processes started by the tasks as well.
+	// __reap waits for the first task which finishes, when it has failed the rest are stopped and the script exits
+	// with its exit code. __throttle keeps at most __jobs running tasks (0 is unlimited)
+	bashParallelPrelude = `__pids=()
+__names=()
+__start() { __pids+=("$1"); __names+=("$2"); }
+__stop() {
+	local pid
+	for pid in ${__pids[@]+"${__pids[@]}"}; do
+		kill -TERM -- "-${pid}" 2>/dev/null || true
+	done
+	wait || true
+}
+trap '__stop; exit 130' INT
+trap '__stop; exit 143' TERM
+__reap() {
+	local i pid name code
+	while true; do
+		for i in "${!__pids[@]}"; do
+			pid="${__pids[i]}"
+			if kill -0 "${pid}" 2>/dev/null; then
+				continue
+			fi
+			name="${__names[i]}"
+			unset '__pids[i]' '__names[i]'
+			__pids=(${__pids[@]+"${__pids[@]}"})
+			__names=(${__names[@]+"${__names[@]}"})
+			code=0
+			wait "${pid}" || code=$?
+			if [ "${code}" -ne 0 ]; then
+				__log "task ${name} failed with exit code ${code}, stopping the job"
+				__stop
+				exit "${code}"
+			fi
+			return 0
+		done
+		sleep 0.05
+	done
+}
+__throttle() { while [ "${__jobs}" -gt 0 ] && [ "${#__pids[@]}" -ge "${__jobs}" ]; do __reap; done; }
+__wait_stage() { while [ "${#__pids[@]}" -gt 0 ]; do __reap; done; }
+`
+)
+
+// writeBash writes hardened bash script. It stops on the first failing command (strict mode), every task
+// is wrapped in named section with timestamped start/end markers and runs in its own subshell, the ERR trap reports
+// the failing task name and its exit code. The commands are embedded as quoted here-documents (see embedCommand), the commands
+// with retry policy are retried before the script stops (see bashRetryPrelude)
+func writeBash(w http.ResponseWriter, p *Plan) error {
+	var b strings.Builder
+	b.WriteString(bashHeader + "\n")
+	b.WriteString("set -euo pipefail\n")
+	writeDroppedComments(&b, p.Dropped)
+	b.WriteString(bashPrelude)
+	if hasRetry(p.Commands) {
+		b.WriteString(bashRetryPrelude)
+	}
+
+	for _, command := range p.Commands {
+		fmt.Fprintf(&b, "\n# task %q\n", command.Name)
+		fmt.Fprintf(&b, "__task=%s\n", shellQuote(command.Name))
+		b.WriteString("__log \"start task ${__task}\"\n")
+		writeTaskCommand(&b, command)
+		b.WriteString("__log \"end task ${__task}\"\n")
+	}
+	return writeText(w, b.String())
+}
+
+// writeBashParallel writes hardened bash script which runs every level (stage) of independent tasks as background
+// jobs and waits for the whole stage before starting the next one. At most Plan.Parallelism tasks run at the same
+// time, the tasks are reaped as they finish and the first failing one stops the others and the script with its
+// exit code. Job control (set -m) is enabled only while the task is started, so it gets its own process group.
+// The commands with retry policy are retried inside their background job, so the stage waits for the last attempt
+// (see bashRetryPrelude)
+func writeBashParallel(w http.ResponseWriter, p *Plan) error {
+	var b strings.Builder
+	b.WriteString(bashHeader + "\n")
+	b.WriteString("set -euo pipefail\n")
+	writeDroppedComments(&b, p.Dropped)
+	b.WriteString(bashPrelude)
+	for _, level := range p.Levels {
+		if hasRetry(level) {
+			b.WriteString(bashRetryPrelude)
+			break
+		}
+	}
+	fmt.Fprintf(&b, "__jobs=%d\n", p.Parallelism)
+	b.WriteString(bashParallelPrelude)
+
+	for i, level := range p.Levels {
+		fmt.Fprintf(&b, "\n# stage %d\n", i+1)
+		for _, command := range level {
+			fmt.Fprintf(&b, "# task %q\n", command.Name)
+			b.WriteString("__throttle\n")
+			b.WriteString("set -m\n")
+			b.WriteString("(\n")
+			fmt.Fprintf(&b, "__task=%s\n", shellQuote(command.Name))
+			b.WriteString("__log \"start task ${__task}\"\n")
+			writeTaskCommand(&b, command)
+			b.WriteString("__log \"end task ${__task}\"\n")
+			// job control does not redirect the input of background jobs, the script could be read from it
+			b.WriteString(") </dev/null &\n")
+			b.WriteString("set +m\n")
+			fmt.Fprintf(&b, "__start $! %s\n", shellQuote(command.Name))
+		}
+		b.WriteString("__wait_stage\n")
+	}
+	return writeText(w, b.String())
+}
+
+// parseJobs returns the query jobs - maximum number of tasks running at the same time, 0 (unlimited) when it is not set
+// Returns invalidJobsErr
+func parseJobs(r *http.Request) (int, error) {
+	jobs := r.URL.Query().Get(jobsQuery)
+	if jobs == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(jobs)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%w: %s, it should be non negative number", invalidJobsErr, jobs)
+	}
+	return n, nil
+}
+
+// writeBashPlain writes the commands one after another, failing command does not stop the script
+func writeBashPlain(w http.ResponseWriter, p *Plan) error {
+	arr := make([]string, 0, len(p.Commands)+len(p.Dropped)+1)
+	arr = append(arr, bashHeader)
+
+	for _, d := range p.Dropped {
+		arr = append(arr, droppedComment(d))
+	}
+
+	for _, command := range p.Commands {
+		arr = append(arr, command.Script)
+	}
+
+	return writeText(w, strings.Join(arr, "\n"))
+}
+
+func writeDroppedComments(b *strings.Builder, dropped []DroppedTask) {
+	for _, d := range dropped {
+		b.WriteString(droppedComment(d) + "\n")
+	}
+}
+
+func droppedComment(d DroppedTask) string {
+	return fmt.Sprintf("# dropped %q: %q", d.Name, d.Reason)
+}
+
+// shellQuote quotes s as single bash word, single quotes are the only character which should be escaped
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func writeText(w http.ResponseWriter, s string) error {
+	w.WriteHeader(http.StatusOK)
+	_, err := w.Write([]byte(s))
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,407 @@
+package job
+
+import (
+	"bytes"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+var testWriteBashPlain = []struct {
+	name           string
+	responseWriter func() http.ResponseWriter
+	commands       []Command
+	expectedBash   string
+	hasError       bool
+}{
+	{
+		"Test with commands should fail due to write error",
+		newErrorWriter,
+		[]Command{
+			{Name: "c1", Script: "echo hello world"},
+		},
+		"",
+		true,
+	},
+	{
+		"Test with no commands should return only bash header",
+		newRecorder,
+		[]Command{},
+		"#!/usr/bin/env bash",
+		false,
+	},
+	{
+		"Test with commands should return bash script ready for execution",
+		newRecorder,
+		[]Command{
+			{Name: "c1", Script: "echo hello"},
+			{Name: "c2", Script: "echo world"},
+		},
+		"#!/usr/bin/env bash\necho hello\necho world",
+		false,
+	},
+}
+
+func TestWriteBashPlain(t *testing.T) {
+	for _, tt := range testWriteBashPlain {
+		t.Run(tt.name, func(t *testing.T) {
+			w := tt.responseWriter()
+			err := writeBashPlain(w, &Plan{Commands: tt.commands})
+			if tt.hasError {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+
+			rr := w.(*httptest.ResponseRecorder)
+			assert.Equal(t, rr.Body.String(), tt.expectedBash)
+
+		})
+	}
+}
+
+func TestWriteBashPlainShouldCommentDroppedTasks(t *testing.T) {
+	rr := httptest.NewRecorder()
+	err := writeBashPlain(rr, &Plan{
+		Commands: []Command{{Name: "c1", Script: "echo hello"}},
+		Dropped:  []DroppedTask{{Name: "c2", Reason: "skipped by request"}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, "#!/usr/bin/env bash\n# dropped \"c2\": \"skipped by request\"\necho hello", rr.Body.String())
+}
+
+const expectedHardenedBash = `#!/usr/bin/env bash
+set -euo pipefail
+# dropped "c3": "skipped by request"
+__task=''
+__log() { printf '[%s] %s\n' "$(date -u +%Y-%m-%dT%H:%M:%SZ)" "$*" >&2; }
+trap '__code=$?; __log "task ${__task} failed with exit code ${__code}"; exit "${__code}"' ERR
+
+# task "c1"
+__task='c1'
+__log "start task ${__task}"
+IFS= read -r -d '' __script <<'__TASK_EOF' || true
+echo hello
+__TASK_EOF
+( eval "${__script}" ) </dev/null
+__log "end task ${__task}"
+
+# task "it's c2"
+__task='it'\''s c2'
+__log "start task ${__task}"
+IFS= read -r -d '' __script <<'__TASK_EOF' || true
+echo world
+__TASK_EOF
+( eval "${__script}" ) </dev/null
+__log "end task ${__task}"
+`
+
+func TestWriteBash(t *testing.T) {
+	rr := httptest.NewRecorder()
+	err := writeBash(rr, &Plan{
+		Commands: []Command{
+			{Name: "c1", Script: "echo hello"},
+			{Name: "it's c2", Script: "echo world"},
+		},
+		Dropped: []DroppedTask{{Name: "c3", Reason: "skipped by request"}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, expectedHardenedBash, rr.Body.String())
+
+	err = writeBash(ErrorResponseWriter{}, &Plan{})
+	assert.NotNil(t, err)
+}
+
+var testRunBash = []struct {
+	name             string
+	commands         []Command
+	expectedExitCode int
+	expectedStdout   string
+	expectedStderr   []string
+}{
+	{
+		"Test with successful commands should run all of them",
+		[]Command{
+			{Name: "c1", Script: "echo hello"},
+			{Name: "c2", Script: "echo world"},
+		},
+		0,
+		"hello\nworld\n",
+		[]string{"start task c1", "end task c1", "start task c2", "end task c2"},
+	},
+	{
+		"Test with failing command should stop and report the task and exit code",
+		[]Command{
+			{Name: "c1", Script: "echo hello"},
+			{Name: "c2", Script: "(exit 3)"},
+			{Name: "c3", Script: "echo world"},
+		},
+		3,
+		"hello\n",
+		[]string{"start task c2", "task c2 failed with exit code 3"},
+	},
+	{
+		"Test with set +e in command should affect only the task",
+		[]Command{
+			{Name: "c1", Script: "set +e\nfalse\necho hello"},
+			{Name: "c2", Script: "false\necho world"},
+		},
+		1,
+		"hello\n",
+		[]string{"end task c1", "task c2 failed with exit code 1"},
+	},
+	{
+		"Test with failing function in command should stop the script",
+		[]Command{
+			{Name: "c1", Script: "f() { false; echo hello; }\nf"},
+			{Name: "c2", Script: "echo world"},
+		},
+		1,
+		"",
+		[]string{"task c1 failed with exit code 1"},
+	},
+	{
+		"Test with exit 0 in command should end only the task",
+		[]Command{
+			{Name: "c1", Script: "exit 0\necho hello"},
+			{Name: "c2", Script: "echo world"},
+		},
+		0,
+		"world\n",
+		[]string{"end task c1", "start task c2", "end task c2"},
+	},
+	{
+		"Test with exit code in command should stop and report the task",
+		[]Command{
+			{Name: "c1", Script: "exit 5"},
+			{Name: "c2", Script: "echo world"},
+		},
+		5,
+		"",
+		[]string{"start task c1", "task c1 failed with exit code 5"},
+	},
+	{
+		"Test with command reading stdin should not read the script",
+		[]Command{
+			{Name: "a", Script: "cat >/dev/null"},
+			{Name: "b", Script: "read -r line || echo eof"},
+			{Name: "c", Script: "echo world"},
+		},
+		0,
+		"eof\nworld\n",
+		[]string{"end task a", "end task b", "end task c"},
+	},
+	{
+		"Test with failing pipe should stop the script",
+		[]Command{
+			{Name: "c1", Script: "false | true"},
+			{Name: "c2", Script: "echo world"},
+		},
+		1,
+		"",
+		[]string{"task c1 failed with exit code 1"},
+	},
+	{
+		"Test with commands which look like the script should not change it",
+		[]Command{
+			{Name: "c1", Script: "x='__TASK_EOF'\necho \"$x\""},
+			{Name: "c2", Script: "cat <<'EOF'\n$HOME `date`\nEOF"},
+			{Name: "c3", Script: "printf '%s\\n' \"a\\\\b\""},
+		},
+		0,
+		"__TASK_EOF\n$HOME `date`\na\\b\n",
+		[]string{"start task c3"},
+	},
+}
+
+// runScript runs the bash script, the test is skipped when bash is not installed
+func runScript(t *testing.T, script string) (int, string, string) {
+	t.Helper()
+	path, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command(path)
+	cmd.Stdin = strings.NewReader(script)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+
+	exitCode := 0
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		exitCode = exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return exitCode, stdout.String(), stderr.String()
+}
+
+func TestRunBash(t *testing.T) {
+	for _, tt := range testRunBash {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			if err := writeBash(rr, &Plan{Commands: tt.commands}); err != nil {
+				t.Fatal(err)
+			}
+
+			exitCode, stdout, stderr := runScript(t, rr.Body.String())
+			assert.Equal(t, tt.expectedExitCode, exitCode)
+			assert.Equal(t, tt.expectedStdout, stdout)
+			for _, s := range tt.expectedStderr {
+				assert.Contains(t, stderr, s)
+			}
+		})
+	}
+}
+
+func TestWriteBashParallel(t *testing.T) {
+	rr := httptest.NewRecorder()
+	err := writeBashParallel(rr, &Plan{
+		Levels: [][]Command{
+			{{Name: "c1", Script: "echo hello"}, {Name: "c2", Script: "echo world"}},
+			{{Name: "c3", Script: "echo !"}},
+		},
+		Parallelism: 2,
+	})
+	assert.Nil(t, err)
+
+	script := rr.Body.String()
+	assert.Contains(t, script, "set -euo pipefail\n")
+	assert.Contains(t, script, "__jobs=2\n")
+	assert.Contains(t, script, "\n# stage 1\n# task \"c1\"\n__throttle\nset -m\n(\n__task='c1'\n__log \"start task ${__task}\"\n"+
+		"IFS= read -r -d '' __script <<'__TASK_EOF' || true\necho hello\n__TASK_EOF\n( eval \"${__script}\" ) </dev/null\n"+
+		"__log \"end task ${__task}\"\n) </dev/null &\nset +m\n__start $! 'c1'\n")
+	assert.Contains(t, script, "__start $! 'c2'\n__wait_stage\n\n# stage 2\n")
+	assert.True(t, strings.HasSuffix(script, "__start $! 'c3'\n__wait_stage\n"))
+
+	err = writeBashParallel(ErrorResponseWriter{}, &Plan{})
+	assert.NotNil(t, err)
+}
+
+var testRunBashParallel = []struct {
+	name             string
+	levels           [][]Command
+	parallelism      int
+	expectedExitCode int
+	expectedStdout   string
+	expectedStderr   []string
+}{
+	{
+		"Test with single job should run the tasks of a stage one after another",
+		[][]Command{
+			{{Name: "c1", Script: "sleep 0.2; echo hello"}, {Name: "c2", Script: "echo world"}},
+			{{Name: "c3", Script: "echo !"}},
+		},
+		1,
+		0,
+		"hello\nworld\n!\n",
+		[]string{"end task c1", "end task c2", "end task c3"},
+	},
+	{
+		"Test with unlimited jobs should finish the stage before the next one",
+		[][]Command{
+			{{Name: "c1", Script: "sleep 0.2; echo hello"}, {Name: "c2", Script: "sleep 0.2; echo hello"}},
+			{{Name: "c3", Script: "echo world"}},
+		},
+		0,
+		0,
+		"hello\nhello\nworld\n",
+		nil,
+	},
+	{
+		"Test with failing task should stop the job and report the task",
+		[][]Command{
+			{{Name: "c1", Script: "echo hello"}, {Name: "c2", Script: "(exit 4)"}},
+			{{Name: "c3", Script: "echo world"}},
+		},
+		0,
+		4,
+		"hello\n",
+		[]string{"task c2 failed with exit code 4, stopping the job"},
+	},
+	{
+		"Test with fast failing task should stop the slow one",
+		[][]Command{
+			{{Name: "slow", Script: "sleep 1 && echo slow"}, {Name: "c2", Script: "(exit 4)"}},
+			{{Name: "c3", Script: "echo world"}},
+		},
+		0,
+		4,
+		"",
+		[]string{"task c2 failed with exit code 4, stopping the job"},
+	},
+	{
+		"Test with single job busy with slow task should run the others in the free slot",
+		[][]Command{
+			{
+				{Name: "long", Script: "sleep 1; echo long"}, {Name: "s1", Script: "echo s1"},
+				{Name: "s2", Script: "echo s2"}, {Name: "s3", Script: "echo s3"},
+			},
+		},
+		2,
+		0,
+		"s1\ns2\ns3\nlong\n",
+		[]string{"end task long", "end task s3"},
+	},
+	{
+		"Test with task reading input should not read the script",
+		[][]Command{
+			{{Name: "c1", Script: "cat"}, {Name: "c2", Script: "echo hello"}},
+			{{Name: "c3", Script: "echo world"}},
+		},
+		0,
+		0,
+		"hello\nworld\n",
+		nil,
+	},
+}
+
+func TestRunBashParallel(t *testing.T) {
+	for _, tt := range testRunBashParallel {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			if err := writeBashParallel(rr, &Plan{Levels: tt.levels, Parallelism: tt.parallelism}); err != nil {
+				t.Fatal(err)
+			}
+
+			exitCode, stdout, stderr := runScript(t, rr.Body.String())
+			assert.Equal(t, tt.expectedExitCode, exitCode, stderr)
+			assert.Equal(t, tt.expectedStdout, stdout)
+			for _, s := range tt.expectedStderr {
+				assert.Contains(t, stderr, s)
+			}
+		})
+	}
+}
+
+var testParseJobs = []struct {
+	name         string
+	url          string
+	hasError     bool
+	expectedJobs int
+}{
+	{"Test without jobs should return unlimited", "/job", false, 0},
+	{"Test with jobs should return the number", "/job?jobs=4", false, 4},
+	{"Test with negative jobs should return specific error", "/job?jobs=-1", true, 0},
+	{"Test with not number jobs should return specific error", "/job?jobs=all", true, 0},
+}
+
+func TestParseJobs(t *testing.T) {
+	for _, tt := range testParseJobs {
+		t.Run(tt.name, func(t *testing.T) {
+			jobs, err := parseJobs(httptest.NewRequest("POST", tt.url, nil))
+			if tt.hasError {
+				assert.True(t, errors.Is(err, invalidJobsErr))
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expectedJobs, jobs)
+		})
+	}
+}
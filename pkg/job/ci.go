@@ -0,0 +1,110 @@
+package job
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ivanspasov99/golang-api/pkg/config"
+)
+
+const (
+	githubActions = "github-actions"
+	gitlabCI      = "gitlab-ci"
+
+	githubRunsOn = "ubuntu-latest"
+)
+
+// gitlabKeywords are the global keywords of .gitlab-ci.yml, they could not be job names
+var gitlabKeywords = []string{
+	"default", "include", "stages", "variables", "workflow",
+	"image", "services", "cache", "before_script", "after_script", "types",
+	"true", "false", "nil",
+}
+
+type githubWorkflow struct {
+	Name string         `json:"name"`
+	On   map[string]any `json:"on"`
+	Jobs yamlMap        `json:"jobs"`
+}
+
+type githubJob struct {
+	Name      string       `json:"name"`
+	Needs     []string     `json:"needs,omitempty"`
+	RunsOn    string       `json:"runs-on"`
+	Container string       `json:"container"`
+	Steps     []githubStep `json:"steps"`
+}
+
+type githubStep struct {
+	Shell string `json:"shell"`
+	Run   string `json:"run"`
+}
+
+type gitlabJob struct {
+	Stage  string   `json:"stage"`
+	Image  string   `json:"image"`
+	Needs  []string `json:"needs"`
+	Script []string `json:"script"`
+}
+
+// writeGithubActions writes GitHub Actions workflow with job per task, the requires are the job needs. Every job
+// runs the command with bash in container of the configured image (see config.Config.Image) as the tasks could run
+// on different runners. Task names are sanitized into job ids (see githubJobIDs) and reported in TaskIDsHeader
+func writeGithubActions(w http.ResponseWriter, p *Plan) error {
+	conf := config.AppConfig()
+	ids := sanitizeIDs(commandNames(p.Commands), githubJobIDs)
+	if err := setIDsHeader(w, ids); err != nil {
+		return err
+	}
+
+	jobs := make(yamlMap, len(p.Commands))
+	for i, c := range p.Commands {
+		jobs[i] = yamlEntry{Key: ids[c.Name], Value: githubJob{
+			Name:      c.Name,
+			Needs:     requiredIDs(c, ids),
+			RunsOn:    githubRunsOn,
+			Container: taskImage(conf),
+			Steps:     []githubStep{{Shell: "bash", Run: c.Script}},
+		}}
+	}
+
+	workflow := githubWorkflow{
+		Name: "job",
+		On:   map[string]any{"workflow_dispatch": map[string]any{}},
+		Jobs: jobs,
+	}
+	return writeYAML(w, "save as .github/workflows/job.yml", p.Dropped, workflow)
+}
+
+// writeGitlabCI writes .gitlab-ci.yml with stage per level (see Plan.Levels) and job per task, the requires are the
+// job needs, so the job starts as soon as its requires finish. Task names are sanitized into job names which are not
+// global keywords (see gitlabKeywords) and reported in TaskIDsHeader
+func writeGitlabCI(w http.ResponseWriter, p *Plan) error {
+	conf := config.AppConfig()
+	ids := sanitizeIDs(commandNames(p.Commands), makeIDs, gitlabKeywords...)
+	if err := setIDsHeader(w, ids); err != nil {
+		return err
+	}
+
+	stages := make([]string, len(p.Levels))
+	stageOf := make(map[string]string, len(p.Commands))
+	for i, level := range p.Levels {
+		stages[i] = fmt.Sprintf("stage-%d", i+1)
+		for _, c := range level {
+			stageOf[c.Name] = stages[i]
+		}
+	}
+
+	pipeline := make(yamlMap, 0, len(p.Commands)+1)
+	pipeline = append(pipeline, yamlEntry{Key: "stages", Value: stages})
+	for _, c := range p.Commands {
+		pipeline = append(pipeline, yamlEntry{Key: ids[c.Name], Value: gitlabJob{
+			Stage: stageOf[c.Name],
+			Image: taskImage(conf),
+			// empty needs starts the job without waiting for the earlier stages
+			Needs:  requiredIDs(c, ids),
+			Script: []string{c.Script},
+		}})
+	}
+	return writeYAML(w, "save as .gitlab-ci.yml", p.Dropped, pipeline)
+}
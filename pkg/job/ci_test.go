@@ -0,0 +1,68 @@
+package job
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ivanspasov99/golang-api/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+var testWriteCI = []struct {
+	name        string
+	writer      ResponseWriter
+	plan        Plan
+	golden      string
+	expectedIDs string
+}{
+	{
+		"Test github-actions should write job per task with needs",
+		writeGithubActions,
+		exportPlan,
+		"github-actions.golden",
+		`{"Task 1":"Task_1","task-2":"task-2","task_3":"task_3"}`,
+	},
+	{
+		"Test github-actions should prefix ids which do not start with letter",
+		writeGithubActions,
+		Plan{Commands: []Command{{Name: "1", Script: "echo 1"}, {Name: "_1", Script: "echo _1"}}},
+		"github-actions-ids.golden",
+		`{"1":"_1","_1":"_1_2"}`,
+	},
+	{
+		"Test gitlab-ci should write stage per level and job per task with needs",
+		writeGitlabCI,
+		exportPlan,
+		"gitlab-ci.golden",
+		`{"Task 1":"Task_1","task-2":"task-2","task_3":"task_3"}`,
+	},
+	{
+		"Test gitlab-ci should not use global keywords as job names",
+		writeGitlabCI,
+		Plan{
+			Commands: []Command{{Name: "stages", Script: "echo stages"}, {Name: "true", Script: "echo true"}},
+			Levels:   [][]Command{{{Name: "stages", Script: "echo stages"}, {Name: "true", Script: "echo true"}}},
+		},
+		"gitlab-ci-keywords.golden",
+		`{"stages":"stages_2","true":"true_2"}`,
+	},
+}
+
+func TestWriteCI(t *testing.T) {
+	if err := config.InitConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range testWriteCI {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			err := tt.writer(rr, &tt.plan)
+			assert.Nil(t, err)
+			assertGolden(t, tt.golden, rr.Body.String())
+			assert.JSONEq(t, tt.expectedIDs, rr.Header().Get(TaskIDsHeader))
+
+			err = tt.writer(ErrorResponseWriter{}, &tt.plan)
+			assert.NotNil(t, err)
+		})
+	}
+}
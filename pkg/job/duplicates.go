@@ -0,0 +1,68 @@
+package job
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	duplicatesQuery = "duplicates"
+
+	// rejectDuplicates reports every duplicate task name as validation problem
+	rejectDuplicates = "reject"
+	// mergeDuplicates combines the requires of identically named tasks with identical commands
+	mergeDuplicates = "merge"
+)
+
+// parseDuplicatesPolicy returns the lower case query duplicates policy, rejectDuplicates when it is not set
+// Returns unsupportedDuplicatesPolicyErr
+func parseDuplicatesPolicy(r *http.Request) (string, error) {
+	policy := strings.ToLower(r.URL.Query().Get(duplicatesQuery))
+	switch policy {
+	case "":
+		return rejectDuplicates, nil
+	case rejectDuplicates, mergeDuplicates:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("%w: %s, supported policies are %s, %s", unsupportedDuplicatesPolicyErr, policy, rejectDuplicates, mergeDuplicates)
+	}
+}
+
+// mergeTasks combines identically named tasks with identical commands into the first of them, the requires
+// lists are merged in order without duplicates. Tasks with the same name but different command are kept
+// as they are, so they could be reported by validateJob
+func mergeTasks(tasks []Task) []Task {
+	merged := make([]Task, 0, len(tasks))
+	// index of the first task with name in merged
+	indexes := make(map[string]int, len(tasks))
+	for _, t := range tasks {
+		i, ok := indexes[t.Name]
+		if !ok || merged[i].Command != t.Command {
+			if !ok {
+				indexes[t.Name] = len(merged)
+			}
+			merged = append(merged, t)
+			continue
+		}
+
+		// copy the requires, so the request tasks are not modified
+		required := append([]string{}, merged[i].Required...)
+		for _, r := range t.Required {
+			if !contains(required, r) {
+				required = append(required, r)
+			}
+		}
+		merged[i].Required = required
+	}
+	return merged
+}
+
+func contains(arr []string, s string) bool {
+	for _, v := range arr {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package job
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+var testMergeTasks = []struct {
+	name          string
+	tasks         []Task
+	expectedTasks []Task
+}{
+	{
+		"Test without duplicates should return the same tasks",
+		[]Task{
+			{Name: "t1", Command: "c1"},
+			{Name: "t2", Command: "c2", Required: []string{"t1"}},
+		},
+		[]Task{
+			{Name: "t1", Command: "c1"},
+			{Name: "t2", Command: "c2", Required: []string{"t1"}},
+		},
+	},
+	{
+		"Test with identical commands should merge requires into the first task",
+		[]Task{
+			{Name: "t1", Command: "c1", Required: []string{"t2"}},
+			{Name: "t2", Command: "c2"},
+			{Name: "t3", Command: "c3"},
+			{Name: "t1", Command: "c1", Required: []string{"t3", "t2"}},
+		},
+		[]Task{
+			{Name: "t1", Command: "c1", Required: []string{"t2", "t3"}},
+			{Name: "t2", Command: "c2"},
+			{Name: "t3", Command: "c3"},
+		},
+	},
+	{
+		"Test with different commands should keep both tasks",
+		[]Task{
+			{Name: "t1", Command: "c1"},
+			{Name: "t1", Command: "c2"},
+		},
+		[]Task{
+			{Name: "t1", Command: "c1"},
+			{Name: "t1", Command: "c2"},
+		},
+	},
+}
+
+func TestMergeTasks(t *testing.T) {
+	for _, tt := range testMergeTasks {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedTasks, mergeTasks(tt.tasks))
+		})
+	}
+}
+
+func TestMergeTasksShouldNotModifyRequestTasks(t *testing.T) {
+	tasks := []Task{
+		{Name: "t1", Command: "c1", Required: []string{"t2"}},
+		{Name: "t1", Command: "c1", Required: []string{"t3"}},
+	}
+	mergeTasks(tasks)
+	assert.Equal(t, []string{"t2"}, tasks[0].Required)
+}
+
+var testParseDuplicatesPolicy = []struct {
+	name           string
+	url            string
+	hasError       bool
+	expectedPolicy string
+}{
+	{"Test without policy should reject duplicates", "/job", false, rejectDuplicates},
+	{"Test with merge policy should merge duplicates", "/job?duplicates=Merge", false, mergeDuplicates},
+	{"Test with unknown policy should return specific error", "/job?duplicates=keep", true, ""},
+}
+
+func TestParseDuplicatesPolicy(t *testing.T) {
+	for _, tt := range testParseDuplicatesPolicy {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := parseDuplicatesPolicy(httptest.NewRequest("POST", tt.url, nil))
+			if tt.hasError {
+				assert.True(t, errors.Is(err, unsupportedDuplicatesPolicyErr))
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expectedPolicy, policy)
+		})
+	}
+}
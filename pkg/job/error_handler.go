@@ -26,22 +26,61 @@ func HandleError(h HTTPTypeHandler) http.HandlerFunc {
 
 		type ErrorResponse struct {
 			Message string `json:"Message"`
+			// Cycles lists every cycle as "a -> b -> a" path when the job could not be sorted
+			Cycles []string `json:"Cycles,omitempty"`
+			// Problems lists every validation problem of the job
+			Problems []Problem `json:"Problems,omitempty"`
+			// SupportedModes lists the registered modes when the query mode is not supported
+			SupportedModes []string `json:"SupportedModes,omitempty"`
+			// SupportedTypes lists the content types of the modes when the Accept header could not be satisfied
+			SupportedTypes []string `json:"SupportedTypes,omitempty"`
 		}
+		eR := ErrorResponse{}
 
 		// depending on the error could be generated different status code, different responses, server reaction as alerting etc.
 		w.Header().Set("Content-Type", "application/json")
-		switch err {
-		case graph.GraphCycleErr:
+		// errors are wrapped with details, so they are matched with errors.Is
+		var cycleErr *graph.CycleError
+		var validationErr *ValidationError
+		var modeErr *UnsupportedModeError
+		var acceptErr *NotAcceptableError
+		switch {
+		case errors.As(err, &validationErr):
+			w.WriteHeader(http.StatusBadRequest)
+			eR.Problems = validationErr.Problems
+			if len(validationErr.Cycles) > 0 {
+				eR.Cycles = (&graph.CycleError{Cycles: validationErr.Cycles}).Paths()
+			}
+			err = errors.Errorf("Please evaluate tasks. Processing feedback: %s", invalidJobErr.Error())
+		case errors.As(err, &cycleErr):
+			w.WriteHeader(http.StatusBadRequest)
+			eR.Cycles = cycleErr.Paths()
+			err = errors.Errorf("Please evaluate tasks. Processing feedback: %s", err.Error())
+		case errors.Is(err, graph.GraphCycleErr):
 			w.WriteHeader(http.StatusBadRequest)
 			err = errors.Errorf("Please evaluate tasks. Processing feedback: %s", err.Error())
-		case graph.VertexNotFoundErr:
+		case errors.Is(err, graph.VertexNotFoundErr):
 			w.WriteHeader(http.StatusBadRequest)
 			err = errors.Errorf("Please evaluate required tasks as one of the defined one is not existing. Processing feedback: %s", err.Error())
+		case errors.As(err, &modeErr):
+			w.WriteHeader(http.StatusBadRequest)
+			eR.SupportedModes = modeErr.Supported
+			err = errors.Errorf("Please evaluate query parameters. Processing feedback: %s", err.Error())
+		case errors.As(err, &acceptErr):
+			w.WriteHeader(http.StatusNotAcceptable)
+			eR.SupportedTypes = acceptErr.Supported
+			err = errors.Errorf("Please evaluate Accept header. Processing feedback: %s", err.Error())
+		case errors.Is(err, unsupportedOrderErr), errors.Is(err, unknownTargetErr),
+			errors.Is(err, unknownTaskErr), errors.Is(err, unsupportedSkipPolicyErr),
+			errors.Is(err, unsupportedDuplicatesPolicyErr), errors.Is(err, invalidJobsErr),
+			errors.Is(err, unsupportedHighlightErr):
+			w.WriteHeader(http.StatusBadRequest)
+			err = errors.Errorf("Please evaluate query parameters. Processing feedback: %s", err.Error())
 		default:
 			w.WriteHeader(http.StatusInternalServerError)
 		}
 
-		eR := ErrorResponse{Message: err.Error()}
+		eR.Message = err.Error()
 
 		b, err := json.Marshal(eR)
 		if err != nil {
@@ -0,0 +1,92 @@
+package job
+
+import (
+	"fmt"
+	"github.com/ivanspasov99/golang-api/pkg/graph"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+var testHandleError = []struct {
+	name           string
+	err            error
+	expectedStatus int
+	expectedBody   string
+}{
+	{
+		"Test without error should not write response",
+		nil,
+		http.StatusOK,
+		"",
+	},
+	{
+		"Test with cycle error should return all cycle paths",
+		&graph.CycleError{Cycles: [][]string{{"t1", "t2", "t1"}, {"t3", "t3"}}},
+		http.StatusBadRequest,
+		`{"Message":"Please evaluate tasks. Processing feedback: there is cycle in the graph: t1 -> t2 -> t1; t3 -> t3","Cycles":["t1 -> t2 -> t1","t3 -> t3"]}`,
+	},
+	{
+		"Test with validation error should return all problems",
+		&ValidationError{Problems: []Problem{
+			{Pointer: "/tasks/0/name", Message: "task name is empty"},
+			{Pointer: "/tasks/1/requires/0", Message: `required task "t5" does not exist`},
+		}},
+		http.StatusBadRequest,
+		`{"Message":"Please evaluate tasks. Processing feedback: invalid job","Problems":[{"pointer":"/tasks/0/name","message":"task name is empty"},{"pointer":"/tasks/1/requires/0","message":"required task \"t5\" does not exist"}]}`,
+	},
+	{
+		"Test with validation error with cycles should return the cycle paths",
+		&ValidationError{
+			Problems: []Problem{{Pointer: "/tasks/0/requires/0", Message: "tasks form cycle t1 -> t2 -> t1"}},
+			Cycles:   [][]string{{"t1", "t2", "t1"}},
+		},
+		http.StatusBadRequest,
+		`{"Message":"Please evaluate tasks. Processing feedback: invalid job","Cycles":["t1 -> t2 -> t1"],"Problems":[{"pointer":"/tasks/0/requires/0","message":"tasks form cycle t1 -> t2 -> t1"}]}`,
+	},
+	{
+		"Test with wrapped vertex not found error should return bad request",
+		fmt.Errorf("%w, Vertex: t2", graph.VertexNotFoundErr),
+		http.StatusBadRequest,
+		`{"Message":"Please evaluate required tasks as one of the defined one is not existing. Processing feedback: vertex not found, Vertex: t2"}`,
+	},
+	{
+		"Test with unsupported mode error should return supported modes",
+		&UnsupportedModeError{Mode: "bsh", Supported: []string{"bash", "json"}},
+		http.StatusBadRequest,
+		`{"Message":"Please evaluate query parameters. Processing feedback: unsupported mode: bsh, supported modes are bash, json","SupportedModes":["bash","json"]}`,
+	},
+	{
+		"Test with not acceptable error should return supported types",
+		&NotAcceptableError{Accept: "text/plain", Supported: []string{"application/json"}},
+		http.StatusNotAcceptable,
+		`{"Message":"Please evaluate Accept header. Processing feedback: not acceptable: text/plain, supported types are application/json","SupportedTypes":["application/json"]}`,
+	},
+	{
+		"Test with unknown error should return internal server error",
+		fmt.Errorf("unknown"),
+		http.StatusInternalServerError,
+		`{"Message":"unknown"}`,
+	},
+}
+
+func TestHandleError(t *testing.T) {
+	for _, tt := range testHandleError {
+		t.Run(tt.name, func(t *testing.T) {
+			h := func(w http.ResponseWriter, r *http.Request) error {
+				return tt.err
+			}
+
+			rr := httptest.NewRecorder()
+			HandleError(h).ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/job", nil))
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedBody == "" {
+				assert.Empty(t, rr.Body.String())
+				return
+			}
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package job
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ivanspasov99/golang-api/pkg/config"
+	"gopkg.in/yaml.v3"
+)
+
+// taskImage returns the configured image (see config.Config.Image) in which the exported tasks run
+func taskImage(conf config.Config) string {
+	return fmt.Sprintf("%s:%s", conf.Image.Name, conf.Image.Tag)
+}
+
+// writeYAML writes the documents as multi document YAML, the first comments are the usage and the dropped tasks.
+// The documents are encoded through their JSON encoding (see yamlNode), so k8s.io/api types and the export types
+// are written with their json tags and the order of their fields is kept
+func writeYAML(w http.ResponseWriter, usage string, dropped []DroppedTask, documents ...any) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated from job, %s\n", usage)
+	writeDroppedComments(&b, dropped)
+	for _, d := range documents {
+		node, err := yamlNode(d)
+		if err != nil {
+			return err
+		}
+		b.WriteString("---\n")
+		encoder := yaml.NewEncoder(&b)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(node); err != nil {
+			return err
+		}
+		if err := encoder.Close(); err != nil {
+			return err
+		}
+	}
+	return writeText(w, b.String())
+}
+
+// yamlNode returns YAML node of the JSON encoding of v. JSON is YAML, so it is decoded as it is and only the flow
+// style and the quotes are dropped, the encoder quotes the strings which would be read as other type
+func yamlNode(v any) (*yaml.Node, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(b, &node); err != nil {
+		return nil, err
+	}
+	blockStyle(&node)
+	return &node, nil
+}
+
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, n := range node.Content {
+		blockStyle(n)
+	}
+}
+
+// yamlMap is mapping which keeps the order of the entries, Ex: the CI jobs are written in the plan order
+type yamlMap []yamlEntry
+
+type yamlEntry struct {
+	Key   string
+	Value any
+}
+
+func (m yamlMap) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, e := range m {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, err := json.Marshal(e.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(e.Value)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
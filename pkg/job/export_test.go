@@ -0,0 +1,23 @@
+package job
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteYAML(t *testing.T) {
+	rr := httptest.NewRecorder()
+	err := writeYAML(rr, "usage", []DroppedTask{{Name: "t1", Reason: "skipped by request"}},
+		yamlMap{{Key: "true", Value: "1"}, {Key: "b", Value: true}, {Key: "a", Value: []string{"x\ny"}}},
+		map[string]any{"empty": map[string]any{}},
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, "# Generated from job, usage\n# dropped \"t1\": \"skipped by request\"\n"+
+		"---\n\"true\": \"1\"\nb: true\na:\n  - |-\n    x\n    y\n"+
+		"---\nempty: {}\n", rr.Body.String())
+
+	err = writeYAML(ErrorResponseWriter{}, "usage", nil, yamlMap{})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,42 @@
+package job
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+var exportCommands = []Command{
+	{Name: "Task 1", Script: "touch /tmp/file1"},
+	{Name: "task_3", Script: "echo 'Hello World!' > /tmp/file1", Requires: []string{"Task 1"}},
+	{Name: "task-2", Script: "cat /tmp/file1\nrm /tmp/file1", Requires: []string{"Task 1", "task_3"}},
+}
+
+// exportPlan is the plan of the golden file tests, the task names require sanitizing
+var exportPlan = Plan{
+	Commands: exportCommands,
+	Levels:   [][]Command{exportCommands[:1], exportCommands[1:2], exportCommands[2:]},
+	Dropped:  []DroppedTask{{Name: "task-4", Reason: "skipped by request"}},
+}
+
+// assertGolden compares got with testdata/name, go test -update writes got to the file instead
+func assertGolden(t *testing.T, name string, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, string(expected), got)
+}
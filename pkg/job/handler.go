@@ -1,20 +1,36 @@
 package job
 
 import (
-	"encoding/json"
 	"fmt"
 	"github.com/ivanspasov99/golang-api/pkg/graph"
 	"github.com/ivanspasov99/golang-api/pkg/logging"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	"io"
 	"net/http"
+	"strings"
 )
 
 var (
 	requestTaskDoesNotExistErr = errors.New("request task does not exist in the sorted ones")
 
 	commandBufferSizeErr = errors.New("sorted tasks are more than the passed buffer size")
+
+	unsupportedOrderErr = errors.New("unsupported order")
+
+	unknownTargetErr = errors.New("unknown target")
+
+	unknownTaskErr = errors.New("unknown task")
+
+	unsupportedSkipPolicyErr = errors.New("unsupported skip policy")
+
+	unsupportedDuplicatesPolicyErr = errors.New("unsupported duplicates policy")
+
+	invalidJobsErr = errors.New("invalid jobs")
+)
+
+const (
+	inputOrder   = "input"
+	lexicalOrder = "lexical"
 )
 
 type Job struct {
@@ -25,22 +41,51 @@ type Task struct {
 	Name     string   `json:"name"`
 	Command  string   `json:"command"`
 	Required []string `json:"requires"`
+	// Retry is optional retry policy of the failing command
+	Retry *Retry `json:"retry,omitempty"`
 }
 
 type Command struct {
 	Name   string `json:"name"`
 	Script string `json:"command"`
+	Retry  *Retry `json:"retry,omitempty"`
+	// Requires are the names of the required commands which are part of the plan
+	// they are used by the writers which keep the dependency edges
+	Requires []string `json:"-"`
+}
+
+// Plan is the processed Job which is passed to the ResponseWriter
+type Plan struct {
+	// Commands are ordered for sequential execution
+	Commands []Command
+	// Levels groups the commands into stages, where every command depends only on commands from earlier stages
+	Levels [][]Command
+	// Dropped are the tasks removed from the job by the request
+	Dropped []DroppedTask
+	// Parallelism is the maximum number of commands running at the same time for the parallel writers and the runs,
+	// 0 is unlimited
+	Parallelism int
+	// Graph is the graph of the planned tasks, the edges point from the task to the required task
+	Graph *graph.NamedGraph
+	// Cycles are set only when the job with cycles is drawn (see writeCyclicGraph), Ex: [a b a]
+	Cycles [][]string
+	// Highlight is used by the graph writers
+	Highlight Highlight
 }
 
 type Graph interface {
 	TopologicalSort() ([]string, error)
+	StableTopologicalSort(order graph.SortOrder) ([]string, error)
+	Levels(order graph.SortOrder) ([][]string, error)
 	Vertex(name string) (*graph.Vertex, error)
-	AddVertex(name string)
+	AddVertex(name string) error
 	AddEdge(from, to *graph.Vertex) error
 }
 
 // Handle processes Job which tasks are being sorted in required order and returned
-// as commands ready for execution. Response format depends on the query mode
+// as commands ready for execution. Response format depends on the query mode or the Accept header, the order of
+// independent tasks depends on the query order (see sortGraph). The job could be reduced to the
+// query targets (see selectTargets) and some tasks could be skipped (see skipTasks)
 // Internally it is using graph.DirectedGraph which is doing sorting in linear complexity
 // A Job is a collection of tasks, where each Task has a name and a shell command. Tasks may
 // depend on other tasks and require that those are executed beforehand.
@@ -51,51 +96,133 @@ func Handle(w http.ResponseWriter, r *http.Request) error {
 		return fmt.Errorf("method not allowed")
 	}
 
-	b, err := io.ReadAll(r.Body)
+	// the response depends on the Accept header when the query mode is not set
+	w.Header().Set("Vary", "Accept")
+	// the mode is checked first, so the unsupported one fails before the job is processed
+	mode, err := negotiateMode(r)
+	if err != nil {
+		return err
+	}
+
 	defer r.Body.Close()
+	j, err := DecodeJob(r.Body)
+	if err != nil {
+		return err
+	}
+
+	plan, err := newPlan(r, j, mode.EmbedsCommands)
+	if err != nil {
+		var validationErr *ValidationError
+		if mode.DrawsCycles && errors.As(err, &validationErr) {
+			return writeCyclicGraph(w, r, mode, j, err)
+		}
+		return err
+	}
+
+	plan.Highlight, err = parseHighlight(r)
 	if err != nil {
 		return err
 	}
 
-	j := Job{}
-	if err := json.Unmarshal(b, &j); err != nil {
+	if err := setDroppedHeader(w, plan.Dropped); err != nil {
+		return err
+	}
+	// the mode writer is used like factory method but for function as golang allows it
+	// there is a rule which defines if we should use struct or function
+	// if the processing does not require a state -> function
+	// if the processing requires a state -> struct
+	if err := writePlan(w, mode, &plan); err != nil {
 		return err
 	}
+	logging.Println(r.Context(), zerolog.InfoLevel, "Response have been sent")
+	return nil
+}
+
+// PlanJob validates the job and plans it with the query parameters of the request (order, target, skip, etc.) as
+// Handle does. The commands are validated as shell scripts, so the plan is ready for execution
+func PlanJob(r *http.Request, j Job) (Plan, error) {
+	return newPlan(r, j, true)
+}
+
+// newPlan validates the job, builds its graph and orders the commands. The commands are validated as shell scripts
+// when validateShell is set (see Mode.EmbedsCommands)
+func newPlan(r *http.Request, j Job, validateShell bool) (Plan, error) {
+	duplicates, err := parseDuplicatesPolicy(r)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	err = validateJob(j, duplicates == mergeDuplicates)
+	if validateShell {
+		err = joinProblems(err, validateCommands(j))
+	}
+	if err != nil {
+		return Plan{}, err
+	}
+	if duplicates == mergeDuplicates {
+		j.Tasks = mergeTasks(j.Tasks)
+	}
+	logging.Println(r.Context(), zerolog.InfoLevel, "Job has been validated")
 
 	g := graph.NewGraph(len(j.Tasks))
 	if err := populateGraph(j.Tasks, g); err != nil {
-		return err
+		return Plan{}, err
 	}
 	logging.Println(r.Context(), zerolog.InfoLevel, "Graph has been constructed successfully")
 
-	sortedArr, err := g.TopologicalSort()
+	all := j
+	j, g, err = selectTargets(r, j, g)
 	if err != nil {
-		return err
+		return Plan{}, err
+	}
+
+	j, g, dropped, err := skipTasks(r, all, j, g)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	sortedArr, err := sortGraph(r, g)
+	if err != nil {
+		return Plan{}, err
 	}
 	logging.Println(r.Context(), zerolog.InfoLevel, "Topological sort has passed")
 
 	commandBuffer := make([]Command, len(sortedArr))
 	if err := generateCommandOrder(sortedArr, j.Tasks, commandBuffer); err != nil {
-		return err
+		return Plan{}, err
 	}
 
 	logging.Println(r.Context(), zerolog.InfoLevel, "Command order has been generated")
 
-	// used like factory method but for function as golang allows it
-	// there is a rule which defines if we should use struct or function
-	// if the processing does not require a state -> function
-	// if the processing requires a state -> struct
-	writeResponse := getJobModeWriter(r)
-	if err := writeResponse(w, commandBuffer); err != nil {
-		return err
+	levels, err := levelGraph(r, g)
+	if err != nil {
+		return Plan{}, err
 	}
-	logging.Println(r.Context(), zerolog.InfoLevel, "Response have been sent")
-	return nil
+	levelBuffer, err := generateCommandLevels(levels, j.Tasks)
+	if err != nil {
+		return Plan{}, err
+	}
+	logging.Println(r.Context(), zerolog.InfoLevel, "Command levels have been generated")
+
+	parallelism, err := parseJobs(r)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	return Plan{
+		Commands:    commandBuffer,
+		Levels:      levelBuffer,
+		Dropped:     dropped,
+		Parallelism: parallelism,
+		Graph:       g,
+	}, nil
 }
 
 func populateGraph(tasks []Task, g Graph) error {
 	for _, t := range tasks {
-		g.AddVertex(t.Name)
+		if err := g.AddVertex(t.Name); err != nil {
+			return err
+		}
 	}
 
 	for _, t := range tasks {
@@ -116,6 +243,51 @@ func populateGraph(tasks []Task, g Graph) error {
 	return nil
 }
 
+// sortGraph sorts the graph depending on the query order
+// input - Kahn's algorithm where ties are broken by the task order in the request
+// lexical - Kahn's algorithm where ties are broken by task name
+// not set - dfs based topological sort
+func sortGraph(r *http.Request, g Graph) ([]string, error) {
+	order, err := parseOrder(r)
+	if err != nil {
+		return nil, err
+	}
+
+	switch order {
+	case inputOrder:
+		return g.StableTopologicalSort(graph.InputOrder)
+	case lexicalOrder:
+		return g.StableTopologicalSort(graph.LexicalOrder)
+	default:
+		return g.TopologicalSort()
+	}
+}
+
+// levelGraph groups the graph into levels of independent tasks, which are ordered by the query order
+// input order is used when the order is not set
+func levelGraph(r *http.Request, g Graph) ([][]string, error) {
+	order, err := parseOrder(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if order == lexicalOrder {
+		return g.Levels(graph.LexicalOrder)
+	}
+	return g.Levels(graph.InputOrder)
+}
+
+// parseOrder returns the lower case query order or unsupportedOrderErr
+func parseOrder(r *http.Request) (string, error) {
+	order := strings.ToLower(r.URL.Query().Get("order"))
+	switch order {
+	case "", inputOrder, lexicalOrder:
+		return order, nil
+	default:
+		return "", fmt.Errorf("%w: %s, supported orders are %s, %s", unsupportedOrderErr, order, inputOrder, lexicalOrder)
+	}
+}
+
 // generateCommandOrder populates commandBuffer with ordered commands based on sorted tasks and requested tasks
 func generateCommandOrder(sortedTasks []string, requestTasks []Task, commandBuffer []Command) error {
 	if len(sortedTasks) != len(commandBuffer) {
@@ -133,7 +305,47 @@ func generateCommandOrder(sortedTasks []string, requestTasks []Task, commandBuff
 		if !ok {
 			return fmt.Errorf("%w, task: %s", requestTaskDoesNotExistErr, t.Name)
 		}
-		commandBuffer[v] = Command{Name: t.Name, Script: t.Command}
+		commandBuffer[v] = newCommand(t, tmp)
 	}
 	return nil
 }
+
+// generateCommandLevels maps the levels of sorted tasks to levels of commands
+func generateCommandLevels(levels [][]string, requestTasks []Task) ([][]Command, error) {
+	// use map for constant access
+	tmp := make(map[string]Task, len(requestTasks))
+	for _, t := range requestTasks {
+		tmp[t.Name] = t
+	}
+
+	planned := make(map[string]int)
+	for i, level := range levels {
+		for _, name := range level {
+			planned[name] = i
+		}
+	}
+
+	levelBuffer := make([][]Command, len(levels))
+	for i, level := range levels {
+		levelBuffer[i] = make([]Command, len(level))
+		for j, name := range level {
+			t, ok := tmp[name]
+			if !ok {
+				return nil, fmt.Errorf("%w, task: %s", requestTaskDoesNotExistErr, name)
+			}
+			levelBuffer[i][j] = newCommand(t, planned)
+		}
+	}
+	return levelBuffer, nil
+}
+
+// newCommand creates the command of the task, requirements which are not planned (dropped) are left out
+func newCommand(t Task, planned map[string]int) Command {
+	c := Command{Name: t.Name, Script: t.Command, Retry: t.Retry}
+	for _, r := range t.Required {
+		if _, ok := planned[r]; ok {
+			c.Requires = append(c.Requires, r)
+		}
+	}
+	return c
+}
@@ -1,9 +1,11 @@
 package job
 
 import (
+	"fmt"
 	"github.com/ivanspasov99/golang-api/pkg/graph"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
 	"reflect"
 	"testing"
 )
@@ -26,23 +28,34 @@ func (mg MockGraph) TopologicalSort() ([]string, error) {
 	return nil, mg.topologicalError
 }
 
+func (mg MockGraph) StableTopologicalSort(order graph.SortOrder) ([]string, error) {
+	return nil, mg.topologicalError
+}
+
+func (mg MockGraph) Levels(order graph.SortOrder) ([][]string, error) {
+	return nil, mg.topologicalError
+}
+
 func (mg MockGraph) Vertex(name string) (*graph.Vertex, error) {
 	return nil, mg.vertex
 }
 
-func (mg MockGraph) AddVertex(name string) {}
+func (mg MockGraph) AddVertex(name string) error {
+	return nil
+}
 
 func (mg MockGraph) AddEdge(from, to *graph.Vertex) error {
 	return mg.edge
 }
 
 var testGenerateGraph = []struct {
-	name          string
-	tasks         []Task
-	input         Graph
-	expected      *graph.DirectedGraph
-	hasError      bool
-	expectedError error
+	name             string
+	tasks            []Task
+	input            func() Graph
+	expectedVertices []string
+	expectedEdges    []string
+	hasError         bool
+	expectedError    error
 }{
 	{
 		"Test should finish with vertex error",
@@ -50,7 +63,8 @@ var testGenerateGraph = []struct {
 			{Name: "task2"},
 			{Name: "task1", Required: []string{"task2"}},
 		},
-		initErrorGraph(nil, graph.VertexNotFoundErr, nil),
+		func() Graph { return initErrorGraph(nil, graph.VertexNotFoundErr, nil) },
+		nil,
 		nil,
 		true,
 		graph.VertexNotFoundErr,
@@ -61,23 +75,32 @@ var testGenerateGraph = []struct {
 			{Name: "task2"},
 			{Name: "task1", Required: []string{"task2"}},
 		},
-		initErrorGraph(graph.VertexIsNotDefinedErr, nil, nil),
+		func() Graph { return initErrorGraph(graph.VertexIsNotDefinedErr, nil, nil) },
+		nil,
 		nil,
 		true,
 		graph.VertexIsNotDefinedErr,
 	},
 	{
-		"Test with single task",
+		"Test with duplicate tasks should finish with duplicate vertex error",
 		[]Task{
 			{Name: "task1"},
+			{Name: "task1"},
 		},
-		graph.NewGraph(1),
-		&graph.DirectedGraph{
-			Vertices: map[string]*graph.Vertex{
-				"task1": {Name: "task1"},
-			},
-			Edges: map[string]*graph.Edge{},
+		func() Graph { return graph.NewGraph(2) },
+		nil,
+		nil,
+		true,
+		graph.DuplicateVertexErr,
+	},
+	{
+		"Test with single task",
+		[]Task{
+			{Name: "task1"},
 		},
+		func() Graph { return graph.NewGraph(1) },
+		[]string{"task1"},
+		[]string{},
 		false,
 		nil,
 	},
@@ -86,18 +109,9 @@ var testGenerateGraph = []struct {
 		[]Task{
 			{Name: "task1", Required: []string{"task1"}},
 		},
-		graph.NewGraph(1),
-		&graph.DirectedGraph{
-			Vertices: map[string]*graph.Vertex{
-				"task1": {Name: "task1"},
-			},
-			Edges: map[string]*graph.Edge{
-				"task1-task1": {
-					From: &graph.Vertex{Name: "task1"},
-					To:   &graph.Vertex{Name: "task1"},
-				},
-			},
-		},
+		func() Graph { return graph.NewGraph(1) },
+		[]string{"task1"},
+		[]string{"task1-task1"},
 		false,
 		nil,
 	},
@@ -108,24 +122,9 @@ var testGenerateGraph = []struct {
 			{Name: "task2", Required: []string{"task3"}},
 			{Name: "task3"},
 		},
-		graph.NewGraph(3),
-		&graph.DirectedGraph{
-			Vertices: map[string]*graph.Vertex{
-				"task1": {Name: "task1"},
-				"task2": {Name: "task2"},
-				"task3": {Name: "task3"},
-			},
-			Edges: map[string]*graph.Edge{
-				"task1-task2": {
-					From: &graph.Vertex{Name: "task1"},
-					To:   &graph.Vertex{Name: "task2"},
-				},
-				"task2-task3": {
-					From: &graph.Vertex{Name: "task2"},
-					To:   &graph.Vertex{Name: "task3"},
-				},
-			},
-		},
+		func() Graph { return graph.NewGraph(3) },
+		[]string{"task1", "task2", "task3"},
+		[]string{"task1-task2", "task2-task3"},
 		false,
 		nil,
 	},
@@ -134,14 +133,23 @@ var testGenerateGraph = []struct {
 func TestGenerateGraph(t *testing.T) {
 	for _, tt := range testGenerateGraph {
 		t.Run(tt.name, func(t *testing.T) {
-			err := populateGraph(tt.tasks, tt.input)
+			input := tt.input()
+			err := populateGraph(tt.tasks, input)
 			if tt.hasError {
 				assert.NotNil(t, err)
 				assert.True(t, errors.Is(err, tt.expectedError))
 				return
 			}
 			assert.Nil(t, err)
-			assert.True(t, reflect.DeepEqual(tt.input, tt.expected))
+
+			g := input.(*graph.NamedGraph)
+			assert.Equal(t, tt.expectedVertices, g.Keys())
+
+			edges := make([]string, 0, len(tt.expectedEdges))
+			for _, e := range g.Edges() {
+				edges = append(edges, fmt.Sprintf("%s-%s", e.From, e.To))
+			}
+			assert.Equal(t, tt.expectedEdges, edges)
 		})
 	}
 }
@@ -172,6 +180,21 @@ var testGenerateCommandOrder = []struct {
 		false,
 		nil,
 	},
+	{
+		"Test with requirements should keep only the sorted ones",
+		[]string{"t1", "t2"},
+		[]Task{
+			{Name: "t1", Command: "c1"},
+			{Name: "t2", Command: "c2", Required: []string{"t1", "t3"}},
+		},
+		make([]Command, 2),
+		[]Command{
+			{Name: "t1", Script: "c1"},
+			{Name: "t2", Script: "c2", Requires: []string{"t1"}},
+		},
+		false,
+		nil,
+	},
 	{
 		"Test with empty sorted tasks should return empty command buffer",
 		[]string{},
@@ -217,3 +240,129 @@ func TestGenerateCommandOrder(t *testing.T) {
 		})
 	}
 }
+
+var testSortGraph = []struct {
+	name                string
+	url                 string
+	tasks               []Task
+	hasError            bool
+	expectedError       error
+	expectedSortedArray []string
+}{
+	{
+		"Test with input order should keep request order for independent tasks",
+		"/job?order=input",
+		[]Task{{Name: "t3"}, {Name: "t1"}, {Name: "t2", Required: []string{"t3"}}},
+		false,
+		nil,
+		[]string{"t3", "t1", "t2"},
+	},
+	{
+		"Test with lexical order should order independent tasks by name",
+		"/job?order=Lexical",
+		[]Task{{Name: "t3"}, {Name: "t1"}, {Name: "t2", Required: []string{"t3"}}},
+		false,
+		nil,
+		[]string{"t1", "t3", "t2"},
+	},
+	{
+		"Test without order should use default sort",
+		"/job",
+		[]Task{{Name: "t1", Required: []string{"t2"}}, {Name: "t2"}},
+		false,
+		nil,
+		[]string{"t2", "t1"},
+	},
+	{
+		"Test with unknown order should return specific error",
+		"/job?order=random",
+		[]Task{{Name: "t1"}},
+		true,
+		unsupportedOrderErr,
+		nil,
+	},
+	{
+		"Test with cycle should return cycle error",
+		"/job?order=input",
+		[]Task{{Name: "t1", Required: []string{"t2"}}, {Name: "t2", Required: []string{"t1"}}},
+		true,
+		graph.GraphCycleErr,
+		nil,
+	},
+}
+
+func TestSortGraph(t *testing.T) {
+	for _, tt := range testSortGraph {
+		t.Run(tt.name, func(t *testing.T) {
+			g := graph.NewGraph(len(tt.tasks))
+			if err := populateGraph(tt.tasks, g); err != nil {
+				t.Fatal(err)
+			}
+
+			arr, err := sortGraph(httptest.NewRequest("POST", tt.url, nil), g)
+			if tt.hasError {
+				assert.NotNil(t, err)
+				assert.True(t, errors.Is(err, tt.expectedError))
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expectedSortedArray, arr)
+		})
+	}
+}
+
+var testGenerateCommandLevels = []struct {
+	name           string
+	levels         [][]string
+	requestTasks   []Task
+	expectedLevels [][]Command
+	hasError       bool
+	expectedError  error
+}{
+	{
+		"Test with levels should return commands grouped in the same levels",
+		[][]string{{"t1", "t3"}, {"t2"}},
+		[]Task{
+			{Name: "t1", Command: "c1"},
+			{Name: "t2", Command: "c2", Required: []string{"t1", "t3"}},
+			{Name: "t3", Command: "c3"},
+		},
+		[][]Command{
+			{{Name: "t1", Script: "c1"}, {Name: "t3", Script: "c3"}},
+			{{Name: "t2", Script: "c2", Requires: []string{"t1", "t3"}}},
+		},
+		false,
+		nil,
+	},
+	{
+		"Test with empty levels should return empty levels",
+		nil,
+		[]Task{},
+		[][]Command{},
+		false,
+		nil,
+	},
+	{
+		"Test with level task missing in request tasks should return specific error",
+		[][]string{{"t1"}},
+		[]Task{},
+		nil,
+		true,
+		requestTaskDoesNotExistErr,
+	},
+}
+
+func TestGenerateCommandLevels(t *testing.T) {
+	for _, tt := range testGenerateCommandLevels {
+		t.Run(tt.name, func(t *testing.T) {
+			levels, err := generateCommandLevels(tt.levels, tt.requestTasks)
+			if tt.hasError {
+				assert.NotNil(t, err)
+				assert.True(t, errors.Is(err, tt.expectedError))
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expectedLevels, levels)
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package job
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// TaskIDsHeader lists the identifiers of the tasks in the exported formats as json object, task name to identifier
+	TaskIDsHeader = "X-Task-Ids"
+
+	// dnsLabelMaxLen is the maximum length of DNS-1123 label (RFC 1123), used for k8s object names
+	dnsLabelMaxLen = 63
+)
+
+// idSyntax describes the identifiers of an output format
+type idSyntax struct {
+	// sanitize maps name to valid identifier, it returns empty string when nothing valid is left
+	sanitize func(name string) string
+	// separator is put between identifier and the numeric suffix of colliding identifiers
+	separator string
+	// maxLen is the maximum identifier length including the suffix, 0 is unlimited
+	maxLen int
+}
+
+var (
+	// makeIDs are identifiers which do not require escaping in bash, make, etc. (see isIDRune)
+	makeIDs = idSyntax{sanitize: replaceInvalid(isIDRune, '_'), separator: "_"}
+	// dnsLabelIDs are lower case alphanumeric identifiers with '-' which start and end with alphanumeric character
+	dnsLabelIDs = idSyntax{sanitize: dnsLabel, separator: "-", maxLen: dnsLabelMaxLen}
+	// githubJobIDs are identifiers of makeIDs syntax which start with letter or '_'
+	githubJobIDs = idSyntax{sanitize: githubJobID, separator: "_"}
+)
+
+// sanitizeIDs maps every name to unique identifier of the given syntax. Empty identifiers are replaced with "task"
+// and colliding identifiers get numeric suffix, Ex: task_2
+// Reserved identifiers are never returned. Names are processed in order, so the mapping is stable
+func sanitizeIDs(names []string, syntax idSyntax, reserved ...string) map[string]string {
+	used := make(map[string]bool, len(names)+len(reserved))
+	for _, r := range reserved {
+		used[r] = true
+	}
+
+	ids := make(map[string]string, len(names))
+	for _, name := range names {
+		if _, ok := ids[name]; ok {
+			continue
+		}
+
+		base := syntax.sanitize(name)
+		if base == "" {
+			base = "task"
+		}
+
+		id := syntax.truncate(base, "")
+		for i := 2; used[id]; i++ {
+			id = syntax.truncate(base, fmt.Sprintf("%s%d", syntax.separator, i))
+		}
+		used[id] = true
+		ids[name] = id
+	}
+	return ids
+}
+
+// setIDsHeader reports the identifiers of the tasks, so the clients could map them back to the tasks
+func setIDsHeader(w http.ResponseWriter, ids map[string]string) error {
+	b, err := json.Marshal(ids)
+	if err != nil {
+		return err
+	}
+	w.Header().Set(TaskIDsHeader, string(b))
+	return nil
+}
+
+func commandNames(commands []Command) []string {
+	names := make([]string, len(commands))
+	for i, c := range commands {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// truncate appends suffix to base, base is shortened when the identifier would be longer than maxLen
+func (s idSyntax) truncate(base, suffix string) string {
+	if s.maxLen > 0 && len(base)+len(suffix) > s.maxLen {
+		// the identifiers are ASCII, so cutting bytes is safe
+		base = strings.TrimRight(base[:s.maxLen-len(suffix)], s.separator)
+	}
+	return base + suffix
+}
+
+// replaceInvalid returns sanitize function which replaces the runes which are not valid with replacement
+func replaceInvalid(valid func(r rune) bool, replacement rune) func(string) string {
+	return func(name string) string {
+		return strings.Map(func(r rune) rune {
+			if valid(r) {
+				return r
+			}
+			return replacement
+		}, name)
+	}
+}
+
+// isIDRune reports whether r is ASCII letter, digit, '-' or '_', which are valid in most of the identifiers
+func isIDRune(r rune) bool {
+	return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_'
+}
+
+// dnsLabel lower cases name, replaces the invalid runes with '-' and trims the leading and trailing '-'
+func dnsLabel(name string) string {
+	label := replaceInvalid(func(r rune) bool {
+		return r >= 'a' && r <= 'z' || r >= '0' && r <= '9'
+	}, '-')(strings.ToLower(name))
+	if len(label) > dnsLabelMaxLen {
+		label = label[:dnsLabelMaxLen]
+	}
+	return strings.Trim(label, "-")
+}
+
+// githubJobID replaces the invalid runes with '_' and prefixes the identifiers which do not start with letter or '_'
+func githubJobID(name string) string {
+	id := replaceInvalid(isIDRune, '_')(name)
+	if id != "" && (id[0] == '-' || id[0] >= '0' && id[0] <= '9') {
+		id = "_" + id
+	}
+	return id
+}
@@ -0,0 +1,66 @@
+package job
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+var testSanitizeIDs = []struct {
+	name        string
+	syntax      idSyntax
+	names       []string
+	reserved    []string
+	expectedIDs map[string]string
+}{
+	{
+		"Test with valid names should keep them",
+		makeIDs,
+		[]string{"task-1", "task_2"},
+		nil,
+		map[string]string{"task-1": "task-1", "task_2": "task_2"},
+	},
+	{
+		"Test with invalid runes should replace them",
+		makeIDs,
+		[]string{"task 1", "tâsk:2"},
+		nil,
+		map[string]string{"task 1": "task_1", "tâsk:2": "t_sk_2"},
+	},
+	{
+		"Test with collisions should add numeric suffix in order",
+		makeIDs,
+		[]string{"task 1", "task_1", "task:1", "task_1_2"},
+		nil,
+		map[string]string{"task 1": "task_1", "task_1": "task_1_2", "task:1": "task_1_3", "task_1_2": "task_1_2_2"},
+	},
+	{
+		"Test with reserved and empty names should not return them",
+		makeIDs,
+		[]string{"all", ""},
+		[]string{"all"},
+		map[string]string{"all": "all_2", "": "task"},
+	},
+	{
+		"Test with DNS label syntax should lower case and trim names",
+		dnsLabelIDs,
+		[]string{"Task_1", "-task 2-", "task-1", "___"},
+		nil,
+		map[string]string{"Task_1": "task-1", "-task 2-": "task-2", "task-1": "task-1-2", "___": "task"},
+	},
+	{
+		"Test with DNS label syntax should truncate long names with the suffix",
+		dnsLabelIDs,
+		[]string{strings.Repeat("a", 70), strings.Repeat("a", 63) + "b"},
+		nil,
+		map[string]string{strings.Repeat("a", 70): strings.Repeat("a", 63), strings.Repeat("a", 63) + "b": strings.Repeat("a", 61) + "-2"},
+	},
+}
+
+func TestSanitizeIDs(t *testing.T) {
+	for _, tt := range testSanitizeIDs {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedIDs, sanitizeIDs(tt.names, tt.syntax, tt.reserved...))
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package job
+
+import (
+	"net/http"
+
+	"github.com/ivanspasov99/golang-api/pkg/config"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	k8s = "k8s"
+
+	// k8sWaiter is the name of the ServiceAccount, Role and RoleBinding which allow the init containers to watch the Jobs
+	k8sWaiter = "job-task-waiter"
+	// k8sWaitTimeout is the maximum time a task waits for its requires, the Job fails after it
+	k8sWaitTimeout = "24h"
+
+	k8sManagedByLabel = "app.kubernetes.io/managed-by"
+	k8sManagedBy      = "golang-api"
+	k8sTaskLabel      = "golang-api/task"
+	// k8sTaskNameAnnotation keeps the task name, the object name could differ as it is DNS label
+	k8sTaskNameAnnotation = "golang-api/task-name"
+)
+
+// k8sObjectMeta and k8sObject are the metadata of the Argo and Tekton resources, which are not part of k8s.io/api
+// They are marshalled as metav1.ObjectMeta and metav1.TypeMeta without the empty fields
+type k8sObjectMeta struct {
+	Name         string            `json:"name,omitempty"`
+	GenerateName string            `json:"generateName,omitempty"`
+	Namespace    string            `json:"namespace,omitempty"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+type k8sObject struct {
+	APIVersion string        `json:"apiVersion"`
+	Kind       string        `json:"kind"`
+	Metadata   k8sObjectMeta `json:"metadata"`
+}
+
+// writeK8s writes multi document YAML with batch/v1 Job per task. The task runs in container of the configured
+// image (see config.Config.Image) and the task requires are kept with init container, which waits until the
+// required Jobs complete. The init containers use ServiceAccount with permissions to watch the Jobs, so it is
+// written (with its Role and RoleBinding) before the Jobs. Task names are sanitized into DNS labels (see dnsLabelIDs)
+func writeK8s(w http.ResponseWriter, p *Plan) error {
+	conf := config.AppConfig()
+	namespace := conf.K8s.Namespace
+
+	ids := sanitizeIDs(commandNames(p.Commands), dnsLabelIDs, k8sWaiter)
+	if err := setIDsHeader(w, ids); err != nil {
+		return err
+	}
+
+	objects := make([]any, 0, len(p.Commands)+3)
+	objects = append(objects, k8sWaiterObjects(namespace)...)
+	for _, c := range p.Commands {
+		objects = append(objects, newK8sJob(c, ids, namespace, taskImage(conf), conf.K8s.WaitImage))
+	}
+
+	return writeYAML(w, "apply with: kubectl apply -f -", p.Dropped, objects...)
+}
+
+func newK8sJob(c Command, ids map[string]string, namespace, image, waitImage string) *batchv1.Job {
+	labels := map[string]string{k8sManagedByLabel: k8sManagedBy, k8sTaskLabel: ids[c.Name]}
+
+	pod := corev1.PodSpec{
+		RestartPolicy: corev1.RestartPolicyNever,
+		Containers: []corev1.Container{
+			{Name: "task", Image: image, Command: []string{"/bin/sh", "-c", c.Script}},
+		},
+	}
+	if len(c.Requires) > 0 {
+		command := []string{"kubectl", "wait", "--for=condition=complete", "--timeout=" + k8sWaitTimeout, "--namespace=" + namespace}
+		for _, r := range c.Requires {
+			command = append(command, "job/"+ids[r])
+		}
+		pod.ServiceAccountName = k8sWaiter
+		pod.InitContainers = []corev1.Container{{Name: "wait-for-requires", Image: waitImage, Command: command}}
+	}
+
+	// the task is not retried, so the command runs at most once as in the other modes
+	backoffLimit := int32(0)
+	return &batchv1.Job{
+		TypeMeta: metav1.TypeMeta{APIVersion: batchv1.SchemeGroupVersion.String(), Kind: "Job"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        ids[c.Name],
+			Namespace:   namespace,
+			Labels:      labels,
+			Annotations: map[string]string{k8sTaskNameAnnotation: c.Name},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template:     corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: labels}, Spec: pod},
+		},
+	}
+}
+
+// k8sWaiterObjects returns the ServiceAccount of the init containers and the Role and RoleBinding which allow
+// it to watch the Jobs in the namespace
+func k8sWaiterObjects(namespace string) []any {
+	meta := metav1.ObjectMeta{
+		Name:      k8sWaiter,
+		Namespace: namespace,
+		Labels:    map[string]string{k8sManagedByLabel: k8sManagedBy},
+	}
+	return []any{
+		&corev1.ServiceAccount{
+			TypeMeta:   metav1.TypeMeta{APIVersion: corev1.SchemeGroupVersion.String(), Kind: "ServiceAccount"},
+			ObjectMeta: meta,
+		},
+		&rbacv1.Role{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "Role"},
+			ObjectMeta: meta,
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{batchv1.GroupName}, Resources: []string{"jobs"}, Verbs: []string{"get", "list", "watch"}},
+			},
+		},
+		&rbacv1.RoleBinding{
+			TypeMeta:   metav1.TypeMeta{APIVersion: rbacv1.SchemeGroupVersion.String(), Kind: "RoleBinding"},
+			ObjectMeta: meta,
+			RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: k8sWaiter},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: k8sWaiter, Namespace: namespace}},
+		},
+	}
+}
@@ -0,0 +1,65 @@
+package job
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ivanspasov99/golang-api/pkg/config"
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestWriteK8s(t *testing.T) {
+	if err := config.InitConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	err := writeK8s(rr, &Plan{
+		Commands: []Command{
+			{Name: "Task 1", Script: "echo \"$HOME\"\necho 'x'"},
+			{Name: "task-2", Script: "cat /tmp/x", Requires: []string{"Task 1"}},
+		},
+		Dropped: []DroppedTask{{Name: "t4", Reason: "skipped by request"}},
+	})
+	assert.Nil(t, err)
+
+	body := rr.Body.String()
+	assert.True(t, strings.HasPrefix(body, "# Generated from job, apply with: kubectl apply -f -\n# dropped \"t4\": \"skipped by request\"\n"))
+
+	docs := strings.Split(body, "---\n")[1:]
+	objects := make([]batchv1.Job, len(docs))
+	kinds := make([]string, len(docs))
+	for i, doc := range docs {
+		assert.Nil(t, yaml.Unmarshal([]byte(doc), &objects[i]))
+		kinds[i] = objects[i].Kind
+	}
+	assert.Equal(t, []string{"ServiceAccount", "Role", "RoleBinding", "Job", "Job"}, kinds)
+
+	first, second := objects[3], objects[4]
+	assert.Equal(t, "task-1", first.Name)
+	assert.Equal(t, "Task 1", first.Annotations[k8sTaskNameAnnotation])
+	assert.Equal(t, "default", first.Namespace)
+	assert.Empty(t, first.Spec.Template.Spec.InitContainers)
+	assert.Equal(t, []corev1.Container{
+		{Name: "task", Image: "image-name:tag-release", Command: []string{"/bin/sh", "-c", "echo \"$HOME\"\necho 'x'"}},
+	}, first.Spec.Template.Spec.Containers)
+
+	assert.Equal(t, "task-2", second.Name)
+	assert.Equal(t, k8sWaiter, second.Spec.Template.Spec.ServiceAccountName)
+	assert.Equal(t, []corev1.Container{
+		{
+			Name:    "wait-for-requires",
+			Image:   "bitnami/kubectl:1.26",
+			Command: []string{"kubectl", "wait", "--for=condition=complete", "--timeout=24h", "--namespace=default", "job/task-1"},
+		},
+	}, second.Spec.Template.Spec.InitContainers)
+	assert.Equal(t, corev1.RestartPolicyNever, second.Spec.Template.Spec.RestartPolicy)
+	assert.Equal(t, int32(0), *second.Spec.BackoffLimit)
+
+	err = writeK8s(ErrorResponseWriter{}, &Plan{})
+	assert.NotNil(t, err)
+}
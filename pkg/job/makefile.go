@@ -0,0 +1,82 @@
+package job
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	makefile = "make"
+
+	makeAllTarget = "all"
+
+	// makeHeader runs the recipes with bash
+	makeHeader = `SHELL := /bin/bash
+`
+)
+
+// writeMakefile writes GNU Makefile with phony target per task. The prerequisites of a target are the task requires
+// and the command is the recipe, so the dependency edges are kept and make -j could run independent tasks in parallel
+// Task names are sanitized into target names which do not require escaping (see makeIDs), the renamed ones
+// are listed as comments
+func writeMakefile(w http.ResponseWriter, p *Plan) error {
+	targets := sanitizeIDs(commandNames(p.Commands), makeIDs, makeAllTarget)
+	if err := setIDsHeader(w, targets); err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("# Generated from job, run with: make -j\n")
+	for _, d := range p.Dropped {
+		b.WriteString(droppedComment(d) + "\n")
+	}
+	for _, c := range p.Commands {
+		if targets[c.Name] != c.Name {
+			fmt.Fprintf(&b, "# target %s is task %q\n", targets[c.Name], c.Name)
+		}
+	}
+	b.WriteString(makeHeader)
+
+	all := make([]string, len(p.Commands))
+	for i, c := range p.Commands {
+		all[i] = targets[c.Name]
+	}
+	fmt.Fprintf(&b, ".PHONY: %s\n", strings.Join(append([]string{makeAllTarget}, all...), " "))
+	fmt.Fprintf(&b, "\n%s: %s\n", makeAllTarget, strings.Join(all, " "))
+
+	for i, c := range p.Commands {
+		// the variable names are valid in the shell, the target names could contain '-'
+		variable := fmt.Sprintf("TASK_%d", i+1)
+		fmt.Fprintf(&b, "\n# task %q\n", c.Name)
+		b.WriteString(makeDefine(variable, c.Script))
+		fmt.Fprintf(&b, "%s:", targets[c.Name])
+		if prerequisites := requiredIDs(c, targets); len(prerequisites) > 0 {
+			b.WriteString(" " + strings.Join(prerequisites, " "))
+		}
+		fmt.Fprintf(&b, "\n\t$(SHELL) -euo pipefail -c \"$$%s\"\n", variable)
+	}
+	return writeText(w, b.String())
+}
+
+// makeDefine writes the script as exported multi line variable, which is run by the recipe of the task. The recipe
+// lines are not used for the script, as make strips the leading whitespace and the prefixes (@, -, +) of them
+// The variable is expanded when it is exported, so '$' is escaped as '$$'. The empty variable reference $() is put
+// before the lines which start with define or endef, so they do not end the variable, and after the trailing
+// backslash, so make does not join the line with the next one. The rest of the script is passed to the shell as it is
+func makeDefine(variable, script string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "define %s\n", variable)
+	for _, line := range strings.Split(script, "\n") {
+		line = strings.ReplaceAll(line, "$", "$$")
+		if fields := strings.Fields(line); len(fields) > 0 && (fields[0] == "define" || fields[0] == "endef") {
+			line = "$()" + line
+		}
+		if strings.HasSuffix(line, "\\") {
+			line += "$()"
+		}
+		b.WriteString(line + "\n")
+	}
+	fmt.Fprintf(&b, "endef\nexport %s\n", variable)
+	return b.String()
+}
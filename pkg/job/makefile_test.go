@@ -0,0 +1,113 @@
+package job
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"os/exec"
+	"testing"
+)
+
+const expectedMakefile = "# Generated from job, run with: make -j\n" +
+	"# dropped \"t4\": \"skipped by request\"\n" +
+	"# target all_2 is task \"all\"\n" +
+	"# target task_3 is task \"task 3\"\n" +
+	"SHELL := /bin/bash\n" +
+	".PHONY: all t1 all_2 task_3\n" +
+	"\n" +
+	"all: t1 all_2 task_3\n" +
+	"\n" +
+	"# task \"t1\"\n" +
+	"define TASK_1\n" +
+	"x=hello; echo \"$$x\"\n" +
+	"endef\n" +
+	"export TASK_1\n" +
+	"t1:\n" +
+	"\t$(SHELL) -euo pipefail -c \"$$TASK_1\"\n" +
+	"\n" +
+	"# task \"all\"\n" +
+	"define TASK_2\n" +
+	"printf 'a\\tb\\n'\n" +
+	"endef\n" +
+	"export TASK_2\n" +
+	"all_2: t1\n" +
+	"\t$(SHELL) -euo pipefail -c \"$$TASK_2\"\n" +
+	"\n" +
+	"# task \"task 3\"\n" +
+	"define TASK_3\n" +
+	"if true; then\n" +
+	"\techo multi\n" +
+	"fi\n" +
+	"endef\n" +
+	"export TASK_3\n" +
+	"task_3: t1 all_2\n" +
+	"\t$(SHELL) -euo pipefail -c \"$$TASK_3\"\n"
+
+var makefileCommands = []Command{
+	{Name: "t1", Script: `x=hello; echo "$x"`},
+	{Name: "all", Script: `printf 'a\tb\n'`, Requires: []string{"t1"}},
+	{Name: "task 3", Script: "if true; then\n\techo multi\nfi", Requires: []string{"t1", "all"}},
+}
+
+func TestWriteMakefile(t *testing.T) {
+	rr := httptest.NewRecorder()
+	err := writeMakefile(rr, &Plan{
+		Commands: makefileCommands,
+		Dropped:  []DroppedTask{{Name: "t4", Reason: "skipped by request"}},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, expectedMakefile, rr.Body.String())
+
+	err = writeMakefile(ErrorResponseWriter{}, &Plan{})
+	assert.NotNil(t, err)
+}
+
+var testRunMakefile = []struct {
+	name           string
+	commands       []Command
+	expectedStdout string
+}{
+	{
+		"Test with dependent tasks should run them in order",
+		makefileCommands,
+		"hello\na\tb\nmulti\n",
+	},
+	{
+		"Test with recipe prefixes and indentation should keep the script",
+		[]Command{{Name: "t1", Script: "cat <<EOF\n- item\n@at\n+plus\n\tindented\n  spaced\nEOF"}},
+		"- item\n@at\n+plus\n\tindented\n  spaced\n",
+	},
+	{
+		"Test with make directives should keep the script",
+		[]Command{{Name: "t1", Script: "cat <<'EOF'\ndefine x\n  endef\nline \\\nnext # not comment\n$(x) ${HOME:+set}\nEOF"}},
+		"define x\n  endef\nline \\\nnext # not comment\n$(x) ${HOME:+set}\n",
+	},
+}
+
+func TestRunMakefile(t *testing.T) {
+	path, err := exec.LookPath("make")
+	if err != nil {
+		t.Skip("make is not installed")
+	}
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	for _, tt := range testRunMakefile {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			if err := writeMakefile(rr, &Plan{Commands: tt.commands}); err != nil {
+				t.Fatal(err)
+			}
+
+			var stdout, stderr bytes.Buffer
+			cmd := exec.Command(path, "-s", "-j", "4", "-f", "-")
+			cmd.Dir = t.TempDir()
+			cmd.Stdin = rr.Body
+			cmd.Stdout = &stdout
+			cmd.Stderr = &stderr
+			assert.Nil(t, cmd.Run(), stderr.String())
+			assert.Equal(t, tt.expectedStdout, stdout.String())
+		})
+	}
+}
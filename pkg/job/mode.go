@@ -3,44 +3,60 @@ package job
 import (
 	"encoding/json"
 	"net/http"
-	"strings"
 )
 
 // ResponseWriter func type is an adapter (interface like function) to allow the use of ordinary functions as Job response writers.
-type ResponseWriter func(http.ResponseWriter, []Command) error
+type ResponseWriter func(http.ResponseWriter, *Plan) error
 
-const bash = "bash"
+const (
+	levels = "levels"
 
-func writeBash(w http.ResponseWriter, commands []Command) error {
-	arr := make([]string, len(commands)+1)
-
-	// we could identify where bash is installed
-	// or use community dependency for generating bash script
-	bashHeader := "#!/usr/bin/env bash"
-
-	arr[0] = bashHeader
+	jsonContentType  = "application/json"
+	shellContentType = "text/x-shellscript"
+	yamlContentType  = "application/yaml"
+)
 
-	for i, command := range commands {
-		arr[i+1] = command.Script
+func init() {
+	for _, m := range []Mode{
+		{Name: defaultMode, ContentType: jsonContentType, Description: "commands in execution order", Writer: writeJSON},
+		{Name: ndjson, ContentType: ndjsonContentType, Description: "commands in execution order streamed as newline delimited json", Writer: writeNDJSON},
+		{Name: levels, ContentType: jsonContentType, Description: "commands grouped in levels of independent commands", Writer: writeLevels},
+		{Name: bash, ContentType: shellContentType, Description: "bash script which stops on the first failing task", EmbedsCommands: true, Writer: writeBash},
+		{Name: bashPlain, ContentType: shellContentType, Description: "bash script with the commands one after another", EmbedsCommands: true, Writer: writeBashPlain},
+		{Name: bashParallel, ContentType: shellContentType, Description: "bash script which runs the levels as background jobs", EmbedsCommands: true, Writer: writeBashParallel},
+		{Name: makefile, ContentType: "text/x-makefile", Description: "GNU Makefile with target per task", EmbedsCommands: true, Writer: writeMakefile},
+		{Name: k8s, ContentType: yamlContentType, Description: "Kubernetes batch/v1 Job per task", Writer: writeK8s},
+		{Name: argo, ContentType: yamlContentType, Description: "Argo Workflow with DAG template", Writer: writeArgo},
+		{Name: tekton, ContentType: yamlContentType, Description: "Tekton Pipeline with task per task", Writer: writeTekton},
+		{Name: githubActions, ContentType: yamlContentType, Description: "GitHub Actions workflow with job per task", Writer: writeGithubActions},
+		{Name: gitlabCI, ContentType: yamlContentType, Description: "GitLab CI pipeline with stage per level", Writer: writeGitlabCI},
+		{Name: dot, ContentType: "text/vnd.graphviz", Description: "Graphviz DOT graph", DrawsCycles: true, Writer: writeDot},
+		{Name: mermaid, ContentType: "text/vnd.mermaid", Description: "Mermaid flowchart", DrawsCycles: true, Writer: writeMermaid},
+		{Name: htmlMode, ContentType: "text/html; charset=utf-8", Description: "HTML page with embedded Mermaid source", DrawsCycles: true, Writer: writeHTML},
+	} {
+		if err := RegisterMode(m); err != nil {
+			panic(err)
+		}
 	}
+}
 
-	s := strings.Join(arr, "\n")
+func writeJSON(w http.ResponseWriter, p *Plan) error {
+	return writeJSONBody(w, p.Commands)
+}
 
-	w.WriteHeader(http.StatusOK)
-	_, err := w.Write([]byte(s))
-	if err != nil {
-		return err
-	}
-	return nil
+// writeLevels writes the commands grouped in stages, as array of arrays of commands
+// the commands of a stage do not depend on each other and could be executed in parallel
+func writeLevels(w http.ResponseWriter, p *Plan) error {
+	return writeJSONBody(w, p.Levels)
 }
 
-func writeJSON(w http.ResponseWriter, commands []Command) error {
-	jsonResp, err := json.Marshal(commands)
+func writeJSONBody(w http.ResponseWriter, body any) error {
+	jsonResp, err := json.Marshal(body)
 	if err != nil {
 		return err
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", jsonContentType)
 	w.WriteHeader(http.StatusOK)
 	_, err = w.Write(jsonResp)
 	if err != nil {
@@ -48,13 +64,3 @@ func writeJSON(w http.ResponseWriter, commands []Command) error {
 	}
 	return nil
 }
-
-func getJobModeWriter(r *http.Request) ResponseWriter {
-	mode := r.URL.Query().Get("mode")
-	switch strings.ToLower(mode) {
-	case bash:
-		return writeBash
-	default:
-		return writeJSON
-	}
-}
@@ -22,106 +22,117 @@ func (e ErrorResponseWriter) Write(bytes []byte) (int, error) {
 func (e ErrorResponseWriter) WriteHeader(statusCode int) {
 }
 
-var testWriteBash = []struct {
+// newRecorder and newErrorWriter create new writer for every run of the table tests
+func newRecorder() http.ResponseWriter {
+	return httptest.NewRecorder()
+}
+
+func newErrorWriter() http.ResponseWriter {
+	return ErrorResponseWriter{}
+}
+
+var testWriteJSON = []struct {
 	name           string
-	responseWriter http.ResponseWriter
+	responseWriter func() http.ResponseWriter
 	commands       []Command
-	expectedBash   string
+	expectedJSON   string
 	hasError       bool
 }{
 	{
 		"Test with commands should fail due to write error",
-		ErrorResponseWriter{},
+		newErrorWriter,
 		[]Command{
-			{Name: "c1", Script: "echo hello world"},
+			{Name: "c1", Script: "echo hello"},
 		},
 		"",
 		true,
 	},
 	{
-		"Test with no commands should return only bash header",
-		httptest.NewRecorder(),
-		[]Command{},
-		"#!/usr/bin/env bash",
-		false,
-	},
-	{
-		"Test with commands should return bash script ready for execution",
-		httptest.NewRecorder(),
+		"Test with commands should return json result",
+		newRecorder,
 		[]Command{
 			{Name: "c1", Script: "echo hello"},
 			{Name: "c2", Script: "echo world"},
 		},
-		"#!/usr/bin/env bash\necho hello\necho world",
+		`[{"name":"c1","command":"echo hello"},{"name":"c2","command":"echo world"}]`,
+		false,
+	},
+	{
+		"Test with empty commands should return empty array json",
+		newRecorder,
+		[]Command{},
+		"[]",
 		false,
 	},
 }
 
-func TestWriteBash(t *testing.T) {
-	for _, tt := range testWriteBash {
+func TestWriteJSON(t *testing.T) {
+	for _, tt := range testWriteJSON {
 		t.Run(tt.name, func(t *testing.T) {
-			err := writeBash(tt.responseWriter, tt.commands)
+			w := tt.responseWriter()
+			err := writeJSON(w, &Plan{Commands: tt.commands})
 			if tt.hasError {
 				assert.NotNil(t, err)
 				return
 			}
 			assert.Nil(t, err)
 
-			rr := tt.responseWriter.(*httptest.ResponseRecorder)
-			assert.Equal(t, rr.Body.String(), tt.expectedBash)
+			rr := w.(*httptest.ResponseRecorder)
+			assert.Equal(t, tt.expectedJSON, rr.Body.String())
 
 		})
 	}
 }
 
-var testWriteJSON = []struct {
+var testWriteLevels = []struct {
 	name           string
-	responseWriter http.ResponseWriter
-	commands       []Command
+	responseWriter func() http.ResponseWriter
+	levels         [][]Command
 	expectedJSON   string
 	hasError       bool
 }{
 	{
-		"Test with commands should fail due to write error",
-		ErrorResponseWriter{},
-		[]Command{
-			{Name: "c1", Script: "echo hello"},
+		"Test with levels should fail due to write error",
+		newErrorWriter,
+		[][]Command{
+			{{Name: "c1", Script: "echo hello"}},
 		},
 		"",
 		true,
 	},
 	{
-		"Test with commands should return json result",
-		httptest.NewRecorder(),
-		[]Command{
-			{Name: "c1", Script: "echo hello"},
-			{Name: "c2", Script: "echo world"},
+		"Test with levels should return json array of arrays",
+		newRecorder,
+		[][]Command{
+			{{Name: "c1", Script: "echo hello"}, {Name: "c2", Script: "echo world"}},
+			{{Name: "c3", Script: "echo !"}},
 		},
-		`[{"name":"c1","command":"echo hello"},{"name":"c2","command":"echo world"}]`,
+		`[[{"name":"c1","command":"echo hello"},{"name":"c2","command":"echo world"}],[{"name":"c3","command":"echo !"}]]`,
 		false,
 	},
 	{
-		"Test with empty commands should return empty array json",
-		httptest.NewRecorder(),
-		[]Command{},
+		"Test with empty levels should return empty array json",
+		newRecorder,
+		[][]Command{},
 		"[]",
 		false,
 	},
 }
 
-func TestWriteJSON(t *testing.T) {
-	for _, tt := range testWriteJSON {
+func TestWriteLevels(t *testing.T) {
+	for _, tt := range testWriteLevels {
 		t.Run(tt.name, func(t *testing.T) {
-			err := writeJSON(tt.responseWriter, tt.commands)
+			w := tt.responseWriter()
+			err := writeLevels(w, &Plan{Levels: tt.levels})
 			if tt.hasError {
 				assert.NotNil(t, err)
 				return
 			}
 			assert.Nil(t, err)
 
-			rr := tt.responseWriter.(*httptest.ResponseRecorder)
+			rr := w.(*httptest.ResponseRecorder)
 			assert.Equal(t, tt.expectedJSON, rr.Body.String())
-
+			assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
 		})
 	}
 }
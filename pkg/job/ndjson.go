@@ -0,0 +1,123 @@
+package job
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	ndjson = "ndjson"
+
+	ndjsonContentType = "application/x-ndjson"
+
+	// ndjsonFlushLines is the number of lines written between the flushes, so small jobs are sent at once
+	// and the big ones reach the client while they are written
+	ndjsonFlushLines = 64
+)
+
+// writeNDJSON streams the commands in execution order as newline delimited json, single command per line.
+// Every command is encoded directly into the response, so the whole body is never held in memory.
+// The response is flushed through http.Flusher every ndjsonFlushLines lines and at the end
+func writeNDJSON(w http.ResponseWriter, p *Plan) error {
+	flusher, _ := w.(http.Flusher)
+	w.WriteHeader(http.StatusOK)
+
+	// json.Encoder writes new line after every value
+	encoder := json.NewEncoder(w)
+	for i, c := range p.Commands {
+		if err := encoder.Encode(c); err != nil {
+			return err
+		}
+		if flusher != nil && (i+1)%ndjsonFlushLines == 0 {
+			flusher.Flush()
+		}
+	}
+	if flusher != nil {
+		flusher.Flush()
+	}
+	return nil
+}
+
+// DecodeJob decodes the job from r task by task, so the request body is not held in memory next to the decoded job
+// Unknown fields are ignored as with json.Unmarshal
+func DecodeJob(r io.Reader) (Job, error) {
+	j := Job{}
+	decoder := json.NewDecoder(r)
+
+	t, err := decoder.Token()
+	if err != nil {
+		return j, err
+	}
+	if d, ok := t.(json.Delim); t != nil && (!ok || d != '{') {
+		return j, fmt.Errorf("invalid job: expected {, got %v", t)
+	}
+	// null is empty job as with json.Unmarshal
+	for t != nil && decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return j, err
+		}
+		// the keys are matched case-insensitively as with json.Unmarshal
+		if name, _ := key.(string); !strings.EqualFold(name, "tasks") {
+			var ignored json.RawMessage
+			if err := decoder.Decode(&ignored); err != nil {
+				return j, err
+			}
+			continue
+		}
+
+		j.Tasks, err = decodeTasks(decoder)
+		if err != nil {
+			return j, err
+		}
+	}
+	if t != nil {
+		if err := expectDelim(decoder, '}'); err != nil {
+			return j, err
+		}
+	}
+
+	// the body should contain single json object as with json.Unmarshal
+	if _, err := decoder.Token(); err != io.EOF {
+		return j, fmt.Errorf("invalid job: unexpected data after the job object")
+	}
+	return j, nil
+}
+
+// decodeTasks decodes json array (or null) of tasks element by element
+func decodeTasks(decoder *json.Decoder) ([]Task, error) {
+	t, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, nil
+	}
+	if d, ok := t.(json.Delim); !ok || d != '[' {
+		return nil, fmt.Errorf("invalid job: tasks should be array, got %v", t)
+	}
+
+	var tasks []Task
+	for decoder.More() {
+		var task Task
+		if err := decoder.Decode(&task); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, expectDelim(decoder, ']')
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	t, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := t.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("invalid job: expected %v, got %v", delim, t)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package job
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingFlusher counts the flushes of the recorder
+type countingFlusher struct {
+	*httptest.ResponseRecorder
+	flushes int
+}
+
+func (f *countingFlusher) Flush() {
+	f.flushes++
+	f.ResponseRecorder.Flush()
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	commands := make([]Command, ndjsonFlushLines*2+1)
+	for i := range commands {
+		commands[i] = Command{Name: fmt.Sprintf("t%d", i), Script: fmt.Sprintf("echo %d", i)}
+	}
+
+	w := &countingFlusher{ResponseRecorder: httptest.NewRecorder()}
+	err := writeNDJSON(w, &Plan{Commands: commands})
+	assert.Nil(t, err)
+	// every ndjsonFlushLines lines and at the end
+	assert.Equal(t, 3, w.flushes)
+
+	var lines int
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		var c Command
+		assert.Nil(t, json.Unmarshal(scanner.Bytes(), &c))
+		assert.Equal(t, commands[lines].Name, c.Name)
+		assert.Equal(t, commands[lines].Script, c.Script)
+		lines++
+	}
+	assert.Equal(t, len(commands), lines)
+
+	err = writeNDJSON(ErrorResponseWriter{}, &Plan{Commands: commands})
+	assert.NotNil(t, err)
+}
+
+var testDecodeJob = []struct {
+	name     string
+	body     string
+	hasError bool
+}{
+	{"Test with tasks should decode them", `{"tasks":[{"name":"a","command":"echo a","requires":["b"]},{"name":"b","command":"echo b"}]}`, false},
+	{"Test with unknown fields should ignore them", `{"version":{"major":1},"Tasks":[{"name":"a","command":"echo a","extra":[1,2]}],"other":null}`, false},
+	{"Test with null should decode empty job", `null`, false},
+	{"Test with null tasks should decode empty job", `{"tasks":null}`, false},
+	{"Test with empty object should decode empty job", `{}`, false},
+	{"Test with array should return error", `[{"name":"a"}]`, true},
+	{"Test with tasks object should return error", `{"tasks":{"name":"a"}}`, true},
+	{"Test with invalid task should return error", `{"tasks":[{"name":1}]}`, true},
+	{"Test with trailing data should return error", `{"tasks":[]} {}`, true},
+	{"Test with truncated body should return error", `{"tasks":[{"name":"a"}`, true},
+	{"Test with empty body should return error", ``, true},
+}
+
+func TestDecodeJob(t *testing.T) {
+	for _, tt := range testDecodeJob {
+		t.Run(tt.name, func(t *testing.T) {
+			j, err := DecodeJob(strings.NewReader(tt.body))
+
+			// the decoder should behave as json.Unmarshal
+			expected := Job{}
+			unmarshalErr := json.Unmarshal([]byte(tt.body), &expected)
+			if tt.hasError {
+				assert.NotNil(t, err)
+				assert.NotNil(t, unmarshalErr)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Nil(t, unmarshalErr)
+			assert.Equal(t, expected, j)
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package job
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var notAcceptableErr = errors.New("not acceptable")
+
+// NotAcceptableError is returned when none of the registered modes matches the Accept header. It matches
+// notAcceptableErr with errors.Is
+type NotAcceptableError struct {
+	Accept    string
+	Supported []string
+}
+
+func (e *NotAcceptableError) Error() string {
+	return fmt.Sprintf("%s: %s, supported types are %s", notAcceptableErr, e.Accept, strings.Join(e.Supported, ", "))
+}
+
+// Is makes errors.Is(err, notAcceptableErr) work for NotAcceptableError
+func (e *NotAcceptableError) Is(target error) bool {
+	return target == notAcceptableErr
+}
+
+// mediaRange is single entry of the Accept header, Ex: text/*;q=0.5
+type mediaRange struct {
+	mediaType string
+	subtype   string
+	q         float64
+	// index is the position in the header, it breaks the ties between equal q-values
+	index int
+}
+
+// matches reports whether the range matches the content type and returns its specificity
+// type/subtype is more specific than type/* which is more specific than */*
+func (m mediaRange) matches(contentType string) (int, bool) {
+	mediaType, subtype, ok := splitMediaType(contentType)
+	switch {
+	case !ok:
+		return 0, false
+	case m.mediaType == "*" && m.subtype == "*":
+		return 0, true
+	case m.mediaType == mediaType && m.subtype == "*":
+		return 1, true
+	case m.mediaType == mediaType && m.subtype == subtype:
+		return 2, true
+	default:
+		return 0, false
+	}
+}
+
+// negotiateMode selects the mode of the request. The query mode is used when it is set, otherwise the mode is
+// negotiated with the Accept header (see Registry.Negotiate). defaultMode is used when neither is set
+func negotiateMode(r *http.Request) (Mode, error) {
+	accept := r.Header.Get("Accept")
+	if r.URL.Query().Get(modeQuery) != "" || strings.TrimSpace(accept) == "" {
+		return lookupMode(r)
+	}
+	return modes.Negotiate(accept)
+}
+
+// Negotiate returns the mode which content type is the most acceptable one for the Accept header (RFC 9110).
+// Every mode gets the q-value of the most specific matching range, the highest q-value wins and the ties are
+// broken by the order of the ranges in the header and then by the registration order, so */* selects the first
+// registered mode. Returns *NotAcceptableError when no mode is acceptable
+func (reg *Registry) Negotiate(accept string) (Mode, error) {
+	ranges := parseAccept(accept)
+
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	var best Mode
+	bestQ, bestIndex := 0.0, 0
+	for _, name := range reg.order {
+		m := reg.modes[name]
+		q, index, ok := acceptance(ranges, m.ContentType)
+		if !ok || q <= 0 {
+			continue
+		}
+		if q > bestQ || q == bestQ && index < bestIndex {
+			best, bestQ, bestIndex = m, q, index
+		}
+	}
+	if bestQ == 0 {
+		return Mode{}, &NotAcceptableError{Accept: accept, Supported: reg.contentTypes()}
+	}
+	return best, nil
+}
+
+// contentTypes returns the distinct content types of the modes in registration order, the caller holds the lock
+func (reg *Registry) contentTypes() []string {
+	seen := make(map[string]bool, len(reg.order))
+	var types []string
+	for _, name := range reg.order {
+		t := reg.modes[name].ContentType
+		if !seen[t] {
+			seen[t] = true
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// acceptance returns the q-value and the header position of the most specific range which matches the content type
+func acceptance(ranges []mediaRange, contentType string) (float64, int, bool) {
+	var matched *mediaRange
+	specificity := -1
+	for i := range ranges {
+		s, ok := ranges[i].matches(contentType)
+		if ok && s > specificity {
+			matched, specificity = &ranges[i], s
+		}
+	}
+	if matched == nil {
+		return 0, 0, false
+	}
+	return matched.q, matched.index, true
+}
+
+// parseAccept returns the media ranges of the Accept header, invalid ranges are left out
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for i, item := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(item))
+		if err != nil {
+			continue
+		}
+		main, sub, ok := splitMediaType(mediaType)
+		if !ok || main == "*" && sub != "*" {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(value, 64)
+			if err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType: main, subtype: sub, q: q, index: i})
+	}
+	return ranges
+}
+
+// splitMediaType returns the lower case type and subtype of the content type, the parameters are left out
+func splitMediaType(contentType string) (string, string, bool) {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	main, sub, ok := strings.Cut(strings.ToLower(strings.TrimSpace(mediaType)), "/")
+	if !ok || main == "" || sub == "" {
+		return "", "", false
+	}
+	return main, sub, true
+}
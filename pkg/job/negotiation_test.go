@@ -0,0 +1,79 @@
+package job
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testNegotiate = []struct {
+	name         string
+	accept       string
+	expectedMode string
+	expectedErr  error
+}{
+	{"Test with json should select json", "application/json", defaultMode, nil},
+	{"Test with shell script should select hardened bash", "text/x-shellscript", bash, nil},
+	{"Test with ndjson should select streaming json", "application/x-ndjson", ndjson, nil},
+	{"Test with graphviz should select dot", "text/vnd.graphviz", dot, nil},
+	{"Test with parameters should ignore them", "text/html;charset=utf-8", htmlMode, nil},
+	{"Test with any type should select the first registered mode", "*/*", defaultMode, nil},
+	{"Test with higher q-value should select it", "application/json;q=0.2, text/x-shellscript;q=0.9", bash, nil},
+	{"Test with equal q-values should select the first range", "application/yaml, application/json", k8s, nil},
+	{"Test with specific type should be preferred over wildcard", "text/*;q=0.5, application/yaml", k8s, nil},
+	{"Test with excluded type should use the other ranges", "text/*, text/x-shellscript;q=0", makefile, nil},
+	{"Test with unsupported type should return error", "text/plain", "", notAcceptableErr},
+	{"Test with invalid q-value should leave out the range", "application/json;q=2", "", notAcceptableErr},
+	{"Test with zero q-values only should return error", "*/*;q=0", "", notAcceptableErr},
+}
+
+func TestNegotiate(t *testing.T) {
+	for _, tt := range testNegotiate {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := modes.Negotiate(tt.accept)
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.Equal(t, tt.expectedMode, m.Name)
+		})
+	}
+}
+
+func TestNegotiateNotAcceptable(t *testing.T) {
+	reg := NewRegistry()
+	assert.Nil(t, reg.Register(Mode{Name: "a", ContentType: "application/json", Writer: writeNothing}))
+	assert.Nil(t, reg.Register(Mode{Name: "b", ContentType: "application/json", Writer: writeNothing}))
+	assert.Nil(t, reg.Register(Mode{Name: "c", ContentType: "text/plain", Writer: writeNothing}))
+
+	_, err := reg.Negotiate("text/html")
+	var acceptErr *NotAcceptableError
+	assert.ErrorAs(t, err, &acceptErr)
+	assert.Equal(t, []string{"application/json", "text/plain"}, acceptErr.Supported)
+}
+
+var testHandleAccept = []struct {
+	name                string
+	url                 string
+	accept              string
+	expectedContentType string
+}{
+	{"Test with Accept should select the writer", "/job", "text/x-shellscript", shellContentType},
+	{"Test with query mode should ignore Accept", "/job?mode=json", "text/x-shellscript", jsonContentType},
+	{"Test without Accept and query mode should write json", "/job", "", jsonContentType},
+}
+
+func TestHandleAccept(t *testing.T) {
+	body := `{"tasks":[{"name":"a","command":"echo a"}]}`
+	for _, tt := range testHandleAccept {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, tt.url, strings.NewReader(body))
+			r.Header.Set("Accept", tt.accept)
+
+			rr := httptest.NewRecorder()
+			assert.Nil(t, Handle(rr, r))
+			assert.Equal(t, tt.expectedContentType, rr.Header().Get("Content-Type"))
+			assert.Equal(t, "Accept", rr.Header().Get("Vary"))
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package job
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	unsupportedModeErr = errors.New("unsupported mode")
+
+	duplicateModeErr = errors.New("mode is already registered")
+
+	invalidModeErr = errors.New("invalid mode")
+)
+
+const (
+	modeQuery = "mode"
+
+	// defaultMode is used when the query mode is not set
+	defaultMode = "json"
+)
+
+// Mode is named ResponseWriter which is selected with the query mode
+type Mode struct {
+	// Name is matched case-insensitively with the query mode
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	Description string `json:"description"`
+	// DrawsCycles reports whether the writer could draw job with cycles (see Plan.Cycles)
+	// the validation problems of such job are not returned then
+	DrawsCycles bool `json:"-"`
+	// EmbedsCommands reports whether the writer embeds the commands in generated script, they are validated
+	// with shell parser then (see validateCommands)
+	EmbedsCommands bool           `json:"-"`
+	Writer         ResponseWriter `json:"-"`
+}
+
+// UnsupportedModeError is returned for the query mode which is not registered. It matches unsupportedModeErr
+// with errors.Is
+type UnsupportedModeError struct {
+	Mode      string
+	Supported []string
+}
+
+func (e *UnsupportedModeError) Error() string {
+	return fmt.Sprintf("%s: %s, supported modes are %s", unsupportedModeErr, e.Mode, strings.Join(e.Supported, ", "))
+}
+
+// Is makes errors.Is(err, unsupportedModeErr) work for UnsupportedModeError
+func (e *UnsupportedModeError) Is(target error) bool {
+	return target == unsupportedModeErr
+}
+
+// Registry holds the modes by name, it is safe for concurrent use
+type Registry struct {
+	mu    sync.RWMutex
+	modes map[string]Mode
+	// order keeps the registration order, which breaks the ties of the content negotiation (see Negotiate)
+	order []string
+}
+
+// NewRegistry returns empty Registry
+func NewRegistry() *Registry {
+	return &Registry{modes: make(map[string]Mode)}
+}
+
+// Register adds the mode, the name is stored in lower case. Returns invalidModeErr when the name or the writer
+// is not set and duplicateModeErr when the name is already registered
+func (reg *Registry) Register(m Mode) error {
+	m.Name = strings.ToLower(m.Name)
+	if m.Name == "" || m.Writer == nil {
+		return fmt.Errorf("%w: %q, name and writer are required", invalidModeErr, m.Name)
+	}
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if _, ok := reg.modes[m.Name]; ok {
+		return fmt.Errorf("%w: %s", duplicateModeErr, m.Name)
+	}
+	reg.modes[m.Name] = m
+	reg.order = append(reg.order, m.Name)
+	return nil
+}
+
+// Lookup returns the mode by case-insensitive name or *UnsupportedModeError
+func (reg *Registry) Lookup(name string) (Mode, error) {
+	reg.mu.RLock()
+	m, ok := reg.modes[strings.ToLower(name)]
+	reg.mu.RUnlock()
+	if !ok {
+		return Mode{}, &UnsupportedModeError{Mode: name, Supported: reg.Names()}
+	}
+	return m, nil
+}
+
+// Modes returns the registered modes ordered by name
+func (reg *Registry) Modes() []Mode {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	modes := make([]Mode, 0, len(reg.modes))
+	for _, m := range reg.modes {
+		modes = append(modes, m)
+	}
+	sort.Slice(modes, func(i, j int) bool {
+		return modes[i].Name < modes[j].Name
+	})
+	return modes
+}
+
+// Names returns the names of the registered modes in order
+func (reg *Registry) Names() []string {
+	modes := reg.Modes()
+	names := make([]string, len(modes))
+	for i, m := range modes {
+		names[i] = m.Name
+	}
+	return names
+}
+
+// modes is the registry used by Handle, the job modes are registered in it on init (see mode.go)
+var modes = NewRegistry()
+
+// RegisterMode adds the mode to the registry used by Handle, so other packages could add their own formats
+// It should be called before the server starts, Ex: in init function
+func RegisterMode(m Mode) error {
+	return modes.Register(m)
+}
+
+// lookupMode returns the mode of the query mode, defaultMode is used when it is not set
+func lookupMode(r *http.Request) (Mode, error) {
+	name := r.URL.Query().Get(modeQuery)
+	if name == "" {
+		name = defaultMode
+	}
+	return modes.Lookup(name)
+}
+
+// writePlan writes the plan with the mode writer, the Content-Type is set before
+func writePlan(w http.ResponseWriter, m Mode, p *Plan) error {
+	w.Header().Set("Content-Type", m.ContentType)
+	return m.Writer(w, p)
+}
+
+// HandleModes lists the registered modes as json array ordered by name
+func HandleModes(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet {
+		return fmt.Errorf("method not allowed")
+	}
+	return writeJSONBody(w, modes.Modes())
+}
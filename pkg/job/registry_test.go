@@ -0,0 +1,86 @@
+package job
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeNothing(w http.ResponseWriter, p *Plan) error {
+	return nil
+}
+
+var testRegister = []struct {
+	name        string
+	mode        Mode
+	expectedErr error
+}{
+	{"Test with new mode should register it", Mode{Name: "text", Writer: writeNothing}, nil},
+	{"Test with registered mode in other case should return error", Mode{Name: "TEXT", Writer: writeNothing}, duplicateModeErr},
+	{"Test without name should return error", Mode{Writer: writeNothing}, invalidModeErr},
+	{"Test without writer should return error", Mode{Name: "other"}, invalidModeErr},
+}
+
+func TestRegister(t *testing.T) {
+	reg := NewRegistry()
+	for _, tt := range testRegister {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, reg.Register(tt.mode), tt.expectedErr)
+		})
+	}
+	assert.Equal(t, []string{"text"}, reg.Names())
+}
+
+func TestLookup(t *testing.T) {
+	reg := NewRegistry()
+	assert.Nil(t, reg.Register(Mode{Name: "b", ContentType: "text/plain", Writer: writeNothing}))
+	assert.Nil(t, reg.Register(Mode{Name: "a", ContentType: "text/plain", Writer: writeNothing}))
+
+	m, err := reg.Lookup("A")
+	assert.Nil(t, err)
+	assert.Equal(t, "a", m.Name)
+
+	_, err = reg.Lookup("bsh")
+	assert.ErrorIs(t, err, unsupportedModeErr)
+	assert.Equal(t, "unsupported mode: bsh, supported modes are a, b", err.Error())
+}
+
+func TestHandleUnsupportedMode(t *testing.T) {
+	body := `{"tasks":[{"name":"a","command":"echo a"}]}`
+	err := Handle(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/job?mode=bsh", strings.NewReader(body)))
+
+	var modeErr *UnsupportedModeError
+	assert.ErrorAs(t, err, &modeErr)
+	assert.Equal(t, "bsh", modeErr.Mode)
+	assert.Contains(t, modeErr.Supported, bash)
+}
+
+func TestHandleContentType(t *testing.T) {
+	body := `{"tasks":[{"name":"a","command":"echo a"}]}`
+	rr := httptest.NewRecorder()
+	err := Handle(rr, httptest.NewRequest(http.MethodPost, "/job?mode=Bash", strings.NewReader(body)))
+	assert.Nil(t, err)
+	assert.Equal(t, shellContentType, rr.Header().Get("Content-Type"))
+}
+
+func TestHandleModes(t *testing.T) {
+	rr := httptest.NewRecorder()
+	err := HandleModes(rr, httptest.NewRequest(http.MethodGet, "/modes", nil))
+	assert.Nil(t, err)
+	assert.Equal(t, jsonContentType, rr.Header().Get("Content-Type"))
+
+	var listed []Mode
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &listed))
+	assert.Len(t, listed, len(modes.Names()))
+	for _, m := range listed {
+		assert.NotEmpty(t, m.ContentType, m.Name)
+		assert.NotEmpty(t, m.Description, m.Name)
+	}
+
+	err = HandleModes(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/modes", nil))
+	assert.NotNil(t, err)
+}
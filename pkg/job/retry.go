@@ -0,0 +1,142 @@
+package job
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultRetryMultiplier is used when Retry.Multiplier is not set
+	defaultRetryMultiplier = 2
+
+	// maxRetryAttempts limits Retry.MaxAttempts, so single task could not run forever
+	maxRetryAttempts = 100
+)
+
+// Retry is the retry policy of the task. The failing command is executed again after delay which grows
+// exponentially with every attempt: InitialDelay * Multiplier^(attempt-1), capped at MaxDelay
+type Retry struct {
+	// MaxAttempts is the maximum number of executions including the first one
+	MaxAttempts int `json:"maxAttempts"`
+	// InitialDelay is the delay before the second attempt as Go duration, Ex: "500ms", "2s". Empty is no delay
+	InitialDelay string `json:"initialDelay,omitempty"`
+	// Multiplier grows the delay after every attempt, 0 is defaultRetryMultiplier
+	Multiplier float64 `json:"multiplier,omitempty"`
+	// MaxDelay caps the delay as Go duration, empty is unlimited
+	MaxDelay string `json:"maxDelay,omitempty"`
+	// RetryOn lists the retried exit codes, every non zero exit code is retried when it is empty
+	RetryOn []int `json:"retryOn,omitempty"`
+}
+
+// Attempts returns the maximum number of executions, 1 when there is no retry policy
+func (r *Retry) Attempts() int {
+	if r == nil || r.MaxAttempts < 1 {
+		return 1
+	}
+	return r.MaxAttempts
+}
+
+// Retries reports whether the attempt which has exited with the non zero exit code should be retried,
+// the number of attempts is not checked
+func (r *Retry) Retries(exitCode int) bool {
+	if r == nil || exitCode == 0 {
+		return false
+	}
+	if len(r.RetryOn) == 0 {
+		return true
+	}
+	for _, code := range r.RetryOn {
+		if code == exitCode {
+			return true
+		}
+	}
+	return false
+}
+
+// Delay returns the delay after the failed attempt (1 is the first execution) before the next one
+// The policy should be validated (see validateRetry)
+func (r *Retry) Delay(attempt int) time.Duration {
+	if r == nil {
+		return 0
+	}
+	initial, _ := parseDelay(r.InitialDelay)
+	maxDelay, _ := parseDelay(r.MaxDelay)
+	multiplier := r.Multiplier
+	if multiplier == 0 {
+		multiplier = defaultRetryMultiplier
+	}
+
+	delay := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+	if maxDelay > 0 && delay > float64(maxDelay) {
+		return maxDelay
+	}
+	if delay >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(delay)
+}
+
+// parseDelay parses the delay as Go duration, empty delay is 0
+func parseDelay(delay string) (time.Duration, error) {
+	if delay == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(delay)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("negative delay %s", delay)
+	}
+	return d, nil
+}
+
+// validateRetry returns problem for every invalid field of the retry policy of the task with index i
+func validateRetry(i int, r *Retry) []Problem {
+	if r == nil {
+		return nil
+	}
+
+	var problems []Problem
+	if r.MaxAttempts < 1 || r.MaxAttempts > maxRetryAttempts {
+		problems = append(problems, Problem{
+			Pointer: taskPointer(i, "retry", "maxAttempts"),
+			Message: fmt.Sprintf("retry max attempts should be between 1 and %d", maxRetryAttempts),
+		})
+	}
+	if _, err := parseDelay(r.InitialDelay); err != nil {
+		problems = append(problems, Problem{Pointer: taskPointer(i, "retry", "initialDelay"), Message: fmt.Sprintf("invalid retry delay: %s", err)})
+	}
+	if r.Multiplier != 0 && r.Multiplier < 1 || math.IsInf(r.Multiplier, 0) || math.IsNaN(r.Multiplier) {
+		problems = append(problems, Problem{Pointer: taskPointer(i, "retry", "multiplier"), Message: "retry multiplier should be at least 1"})
+	}
+	if _, err := parseDelay(r.MaxDelay); err != nil {
+		problems = append(problems, Problem{Pointer: taskPointer(i, "retry", "maxDelay"), Message: fmt.Sprintf("invalid retry delay: %s", err)})
+	}
+	for k, code := range r.RetryOn {
+		if code < 1 || code > 255 {
+			problems = append(problems, Problem{
+				Pointer: taskPointer(i, "retry", "retryOn", k),
+				Message: fmt.Sprintf("retried exit code %d should be between 1 and 255", code),
+			})
+		}
+	}
+	return problems
+}
+
+// retryArgs returns the arguments of the bash __retry function - the delays in seconds before every retry and
+// the retried exit codes, both space separated
+func retryArgs(r *Retry) (string, string) {
+	delays := make([]string, r.Attempts()-1)
+	for i := range delays {
+		delays[i] = strconv.FormatFloat(r.Delay(i+1).Seconds(), 'f', -1, 64)
+	}
+	codes := make([]string, len(r.RetryOn))
+	for i, code := range r.RetryOn {
+		codes[i] = strconv.Itoa(code)
+	}
+	return strings.Join(delays, " "), strings.Join(codes, " ")
+}
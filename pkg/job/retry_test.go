@@ -0,0 +1,226 @@
+package job
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testRetryDelay = []struct {
+	name     string
+	retry    *Retry
+	expected []time.Duration
+}{
+	{
+		"Test without policy should not wait",
+		nil,
+		[]time.Duration{0, 0},
+	},
+	{
+		"Test with default multiplier should double the delay",
+		&Retry{MaxAttempts: 4, InitialDelay: "1s"},
+		[]time.Duration{time.Second, 2 * time.Second, 4 * time.Second},
+	},
+	{
+		"Test with max delay should cap the delay",
+		&Retry{MaxAttempts: 5, InitialDelay: "100ms", Multiplier: 3, MaxDelay: "1s"},
+		[]time.Duration{100 * time.Millisecond, 300 * time.Millisecond, 900 * time.Millisecond, time.Second},
+	},
+	{
+		"Test with multiplier 1 should keep the delay",
+		&Retry{MaxAttempts: 3, InitialDelay: "500ms", Multiplier: 1},
+		[]time.Duration{500 * time.Millisecond, 500 * time.Millisecond},
+	},
+}
+
+func TestRetryDelay(t *testing.T) {
+	for _, tt := range testRetryDelay {
+		t.Run(tt.name, func(t *testing.T) {
+			var delays []time.Duration
+			for attempt := 1; attempt <= len(tt.expected); attempt++ {
+				delays = append(delays, tt.retry.Delay(attempt))
+			}
+			assert.Equal(t, tt.expected, delays)
+		})
+	}
+}
+
+func TestRetryRetries(t *testing.T) {
+	var none *Retry
+	assert.Equal(t, 1, none.Attempts())
+	assert.False(t, none.Retries(1))
+
+	every := &Retry{MaxAttempts: 3}
+	assert.Equal(t, 3, every.Attempts())
+	assert.True(t, every.Retries(1))
+	assert.True(t, every.Retries(255))
+	assert.False(t, every.Retries(0))
+
+	listed := &Retry{MaxAttempts: 3, RetryOn: []int{75, 124}}
+	assert.True(t, listed.Retries(124))
+	assert.False(t, listed.Retries(1))
+}
+
+func TestValidateRetry(t *testing.T) {
+	problems := validateRetry(2, &Retry{MaxAttempts: 0, InitialDelay: "soon", Multiplier: 0.5, MaxDelay: "-1s", RetryOn: []int{1, 0, 256}})
+	assert.Equal(t, []Problem{
+		{Pointer: "/tasks/2/retry/maxAttempts", Message: "retry max attempts should be between 1 and 100"},
+		{Pointer: "/tasks/2/retry/initialDelay", Message: `invalid retry delay: time: invalid duration "soon"`},
+		{Pointer: "/tasks/2/retry/multiplier", Message: "retry multiplier should be at least 1"},
+		{Pointer: "/tasks/2/retry/maxDelay", Message: "invalid retry delay: negative delay -1s"},
+		{Pointer: "/tasks/2/retry/retryOn/1", Message: "retried exit code 0 should be between 1 and 255"},
+		{Pointer: "/tasks/2/retry/retryOn/2", Message: "retried exit code 256 should be between 1 and 255"},
+	}, problems)
+
+	assert.Empty(t, validateRetry(0, nil))
+	assert.Empty(t, validateRetry(0, &Retry{MaxAttempts: 1}))
+	assert.Empty(t, validateRetry(0, &Retry{MaxAttempts: 5, InitialDelay: "1s", Multiplier: 1.5, MaxDelay: "1m", RetryOn: []int{1}}))
+}
+
+func TestRetryArgs(t *testing.T) {
+	delays, codes := retryArgs(&Retry{MaxAttempts: 4, InitialDelay: "250ms", MaxDelay: "750ms", RetryOn: []int{1, 75}})
+	assert.Equal(t, "0.25 0.5 0.75", delays)
+	assert.Equal(t, "1 75", codes)
+}
+
+func TestWriteBashRetry(t *testing.T) {
+	rr := httptest.NewRecorder()
+	err := writeBash(rr, &Plan{Commands: []Command{
+		{Name: "c1", Script: "echo hello"},
+		{Name: "c2", Script: "curl example.com", Retry: &Retry{MaxAttempts: 3, InitialDelay: "1s", RetryOn: []int{6, 7}}},
+	}})
+	assert.Nil(t, err)
+
+	script := rr.Body.String()
+	assert.Contains(t, script, bashRetryPrelude)
+	assert.Contains(t, script, "IFS= read -r -d '' __script <<'__TASK_EOF' || true\necho hello\n__TASK_EOF\n( eval \"${__script}\" ) </dev/null\n")
+	assert.Contains(t, script, "IFS= read -r -d '' __script <<'__TASK_EOF' || true\ncurl example.com\n__TASK_EOF\n__retry '1 2' '6 7'\n")
+
+	rr = httptest.NewRecorder()
+	assert.Nil(t, writeBash(rr, &Plan{Commands: []Command{{Name: "c1", Script: "echo hello", Retry: &Retry{MaxAttempts: 1}}}}))
+	assert.NotContains(t, rr.Body.String(), "__retry")
+}
+
+// flakyScript fails with the exit code until its attempt, the attempts are counted in the file
+func flakyScript(file string, succeedOn, exitCode int) string {
+	return fmt.Sprintf("n=$(( $(cat %[1]q 2>/dev/null || echo 0) + 1 ))\necho \"$n\" > %[1]q\n"+
+		"[ \"$n\" -ge %[2]d ] || exit %[3]d\necho \"done on $n\"", file, succeedOn, exitCode)
+}
+
+var testRunBashRetry = []struct {
+	name             string
+	parallel         bool
+	script           func(file string) string
+	retry            *Retry
+	expectedExitCode int
+	expectedStdout   string
+	expectedStderr   []string
+}{
+	{
+		"Test with flaky task should succeed on the last attempt",
+		false,
+		func(file string) string { return flakyScript(file, 3, 7) },
+		&Retry{MaxAttempts: 3, InitialDelay: "10ms"},
+		0,
+		"done on 3\nafter\n",
+		[]string{
+			"task c1 attempt 1/3 exited with code 7", "retrying task c1 in 0.01s", "retrying task c1 in 0.02s",
+			"task c1 attempt 3/3 exited with code 0", "end task c1",
+		},
+	},
+	{
+		"Test with attempts run out should fail the task",
+		false,
+		func(file string) string { return flakyScript(file, 3, 7) },
+		&Retry{MaxAttempts: 2},
+		7,
+		"",
+		[]string{"task c1 attempt 2/2 exited with code 7", "task c1 failed with exit code 7"},
+	},
+	{
+		"Test with other exit code should not retry the task",
+		false,
+		func(file string) string { return flakyScript(file, 3, 7) },
+		&Retry{MaxAttempts: 3, RetryOn: []int{75}},
+		7,
+		"",
+		[]string{"task c1 attempt 1/3 exited with code 7", "task c1 failed with exit code 7"},
+	},
+	{
+		"Test with retried script should run it in strict mode",
+		false,
+		func(string) string { return "false\necho unreachable" },
+		&Retry{MaxAttempts: 2},
+		1,
+		"",
+		[]string{"task c1 attempt 2/2 exited with code 1"},
+	},
+	{
+		"Test with retried script should see the variables and functions of the script",
+		false,
+		func(string) string { return "__log \"inside task ${__task}\"" },
+		&Retry{MaxAttempts: 2},
+		0,
+		"after\n",
+		[]string{"inside task c1", "task c1 attempt 1/2 exited with code 0"},
+	},
+	{
+		"Test with exit 0 in retried script should end only the task",
+		false,
+		func(string) string { return "exit 0\necho unreachable" },
+		&Retry{MaxAttempts: 2},
+		0,
+		"after\n",
+		[]string{"task c1 attempt 1/2 exited with code 0", "end task c2"},
+	},
+	{
+		"Test with retried script reading stdin should not read the script",
+		false,
+		func(string) string { return "cat >/dev/null" },
+		&Retry{MaxAttempts: 2},
+		0,
+		"after\n",
+		[]string{"end task c1", "end task c2"},
+	},
+	{
+		"Test with parallel flaky task should succeed on the last attempt",
+		true,
+		func(file string) string { return flakyScript(file, 2, 7) },
+		&Retry{MaxAttempts: 2},
+		0,
+		"done on 2\nafter\n",
+		[]string{"task c1 attempt 1/2 exited with code 7", "end task c2"},
+	},
+}
+
+func TestRunBashRetry(t *testing.T) {
+	for _, tt := range testRunBashRetry {
+		t.Run(tt.name, func(t *testing.T) {
+			commands := []Command{
+				{Name: "c1", Script: tt.script(filepath.Join(t.TempDir(), "attempts")), Retry: tt.retry},
+				{Name: "c2", Script: "echo after"},
+			}
+			rr := httptest.NewRecorder()
+			var err error
+			if tt.parallel {
+				err = writeBashParallel(rr, &Plan{Levels: [][]Command{commands[:1], commands[1:]}})
+			} else {
+				err = writeBash(rr, &Plan{Commands: commands})
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			exitCode, stdout, stderr := runScript(t, rr.Body.String())
+			assert.Equal(t, tt.expectedExitCode, exitCode, stderr)
+			assert.Equal(t, tt.expectedStdout, stdout)
+			for _, s := range tt.expectedStderr {
+				assert.Contains(t, stderr, s)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package job
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ivanspasov99/golang-api/pkg/graph"
+	"net/http"
+	"strings"
+)
+
+const (
+	targetQuery     = "target"
+	skipQuery       = "skip"
+	skipPolicyQuery = "skipPolicy"
+
+	// cascadePolicy drops the skipped tasks and every task which transitively requires them
+	cascadePolicy = "cascade"
+	// ignorePolicy drops only the skipped tasks, their requirement is treated as satisfied
+	ignorePolicy = "ignore"
+
+	// DroppedTasksHeader lists the tasks dropped from the job as json array of DroppedTask
+	DroppedTasksHeader = "X-Dropped-Tasks"
+)
+
+// DroppedTask is task which has been removed from the job and the reason for it
+type DroppedTask struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// selectTargets reduces the job to the query targets and every task they transitively require, so only the
+// minimal set of commands needed to reach the targets is returned. Job and graph are returned as they are
+// when there are no targets. Returns unknownTargetErr if target does not exist
+func selectTargets(r *http.Request, j Job, g *graph.NamedGraph) (Job, *graph.NamedGraph, error) {
+	targets := r.URL.Query()[targetQuery]
+	if len(targets) == 0 {
+		return j, g, nil
+	}
+
+	var unknown []string
+	for _, t := range targets {
+		if !g.HasVertex(t) {
+			unknown = append(unknown, t)
+		}
+	}
+	if len(unknown) > 0 {
+		return Job{}, nil, fmt.Errorf("%w: %s", unknownTargetErr, strings.Join(unknown, ", "))
+	}
+
+	required, err := g.Descendants(targets...)
+	if err != nil {
+		return Job{}, nil, err
+	}
+
+	sub, err := g.Subgraph(append(required, targets...)...)
+	if err != nil {
+		return Job{}, nil, err
+	}
+	return Job{Tasks: filterTasks(j.Tasks, sub)}, sub, nil
+}
+
+// skipTasks drops the query skip tasks from the job depending on the query skipPolicy (cascade by default)
+// and returns the dropped tasks in the request order. The skipped tasks are looked up in the whole job (all), the ones
+// which are not part of the target selection (see selectTargets) are only reported as dropped. Job and graph are
+// returned as they are when there is nothing to skip
+// Returns unknownTaskErr if skipped task does not exist and unsupportedSkipPolicyErr
+func skipTasks(r *http.Request, all Job, j Job, g *graph.NamedGraph) (Job, *graph.NamedGraph, []DroppedTask, error) {
+	policy := strings.ToLower(r.URL.Query().Get(skipPolicyQuery))
+	if policy == "" {
+		policy = cascadePolicy
+	}
+	if policy != cascadePolicy && policy != ignorePolicy {
+		return Job{}, nil, nil, fmt.Errorf("%w: %s, supported policies are %s, %s", unsupportedSkipPolicyErr, policy, cascadePolicy, ignorePolicy)
+	}
+
+	skipped := r.URL.Query()[skipQuery]
+	if len(skipped) == 0 {
+		return j, g, nil, nil
+	}
+
+	names := make(map[string]bool, len(all.Tasks))
+	for _, t := range all.Tasks {
+		names[t.Name] = true
+	}
+	reasons := make(map[string]string, len(skipped))
+	// selected are the skipped tasks which are part of the graph
+	selected := make([]string, 0, len(skipped))
+	var unknown []string
+	for _, s := range skipped {
+		if !names[s] {
+			unknown = append(unknown, s)
+			continue
+		}
+		reasons[s] = "skipped by request"
+		if g.HasVertex(s) {
+			selected = append(selected, s)
+		}
+	}
+	if len(unknown) > 0 {
+		return Job{}, nil, nil, fmt.Errorf("%w: %s", unknownTaskErr, strings.Join(unknown, ", "))
+	}
+
+	if policy == cascadePolicy {
+		dependents, err := g.Ancestors(selected...)
+		if err != nil {
+			return Job{}, nil, nil, err
+		}
+		for _, d := range dependents {
+			if _, ok := reasons[d]; !ok {
+				reasons[d] = ""
+			}
+		}
+		// every dependent requires at least one dropped task directly
+		for _, d := range dependents {
+			if reasons[d] != "" {
+				continue
+			}
+			required, err := g.Outgoing(d)
+			if err != nil {
+				return Job{}, nil, nil, err
+			}
+			for _, req := range required {
+				if _, ok := reasons[req]; ok {
+					reasons[d] = fmt.Sprintf("requires dropped task %q", req)
+					break
+				}
+			}
+		}
+	}
+
+	kept := make([]string, 0, g.Len())
+	for _, name := range g.Keys() {
+		if _, ok := reasons[name]; !ok {
+			kept = append(kept, name)
+		}
+	}
+	sub, err := g.Subgraph(kept...)
+	if err != nil {
+		return Job{}, nil, nil, err
+	}
+
+	dropped := make([]DroppedTask, 0, len(reasons))
+	for _, t := range all.Tasks {
+		if reason, ok := reasons[t.Name]; ok {
+			dropped = append(dropped, DroppedTask{Name: t.Name, Reason: reason})
+			// tasks with the same name should be reported once
+			delete(reasons, t.Name)
+		}
+	}
+	return Job{Tasks: filterTasks(j.Tasks, sub)}, sub, dropped, nil
+}
+
+// setDroppedHeader lists the dropped tasks in DroppedTasksHeader, so they are reported in every response mode
+func setDroppedHeader(w http.ResponseWriter, dropped []DroppedTask) error {
+	if len(dropped) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(dropped)
+	if err != nil {
+		return err
+	}
+	w.Header().Set(DroppedTasksHeader, string(b))
+	return nil
+}
+
+// filterTasks returns the tasks which are part of the graph in the request order
+func filterTasks(tasks []Task, g *graph.NamedGraph) []Task {
+	filtered := make([]Task, 0, g.Len())
+	for _, t := range tasks {
+		if g.HasVertex(t.Name) {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered
+}
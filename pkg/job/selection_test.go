@@ -0,0 +1,225 @@
+package job
+
+import (
+	"github.com/ivanspasov99/golang-api/pkg/graph"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+var selectionTasks = []Task{
+	{Name: "t1", Command: "c1"},
+	{Name: "t2", Command: "c2", Required: []string{"t3"}},
+	{Name: "t3", Command: "c3", Required: []string{"t1"}},
+	{Name: "t4", Command: "c4", Required: []string{"t2", "t3"}},
+	{Name: "t5", Command: "c5"},
+}
+
+var testSelectTargets = []struct {
+	name          string
+	url           string
+	hasError      bool
+	expectedError error
+	expectedTasks []string
+	expectedSort  []string
+}{
+	{
+		"Test without targets should return the whole job",
+		"/job",
+		false,
+		nil,
+		[]string{"t1", "t2", "t3", "t4", "t5"},
+		[]string{"t1", "t3", "t2", "t4", "t5"},
+	},
+	{
+		"Test with target should return target and every required task",
+		"/job?target=t2",
+		false,
+		nil,
+		[]string{"t1", "t2", "t3"},
+		[]string{"t1", "t3", "t2"},
+	},
+	{
+		"Test with multiple targets should return union of required tasks",
+		"/job?target=t3&target=t5",
+		false,
+		nil,
+		[]string{"t1", "t3", "t5"},
+		[]string{"t1", "t3", "t5"},
+	},
+	{
+		"Test with target without requirements should return only target",
+		"/job?target=t1",
+		false,
+		nil,
+		[]string{"t1"},
+		[]string{"t1"},
+	},
+	{
+		"Test with unknown target should return specific error",
+		"/job?target=t2&target=t6",
+		true,
+		unknownTargetErr,
+		nil,
+		nil,
+	},
+}
+
+func TestSelectTargets(t *testing.T) {
+	for _, tt := range testSelectTargets {
+		t.Run(tt.name, func(t *testing.T) {
+			g := graph.NewGraph(len(selectionTasks))
+			if err := populateGraph(selectionTasks, g); err != nil {
+				t.Fatal(err)
+			}
+
+			j, sub, err := selectTargets(httptest.NewRequest("POST", tt.url, nil), Job{Tasks: selectionTasks}, g)
+			if tt.hasError {
+				assert.NotNil(t, err)
+				assert.True(t, errors.Is(err, tt.expectedError))
+				return
+			}
+			assert.Nil(t, err)
+
+			names := make([]string, len(j.Tasks))
+			for i, task := range j.Tasks {
+				names[i] = task.Name
+			}
+			assert.Equal(t, tt.expectedTasks, names)
+
+			sorted, err := sub.StableTopologicalSort(graph.InputOrder)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expectedSort, sorted)
+		})
+	}
+}
+
+var testSkipTasks = []struct {
+	name            string
+	url             string
+	hasError        bool
+	expectedError   error
+	expectedTasks   []string
+	expectedDropped []DroppedTask
+	expectedSort    []string
+}{
+	{
+		"Test without skip should return the whole job",
+		"/job",
+		false,
+		nil,
+		[]string{"t1", "t2", "t3", "t4", "t5"},
+		nil,
+		[]string{"t1", "t3", "t2", "t4", "t5"},
+	},
+	{
+		"Test with skip should cascade by default",
+		"/job?skip=t3",
+		false,
+		nil,
+		[]string{"t1", "t5"},
+		[]DroppedTask{
+			{Name: "t2", Reason: `requires dropped task "t3"`},
+			{Name: "t3", Reason: "skipped by request"},
+			{Name: "t4", Reason: `requires dropped task "t2"`},
+		},
+		[]string{"t1", "t5"},
+	},
+	{
+		"Test with skip and ignore policy should keep dependents",
+		"/job?skip=t3&skipPolicy=ignore",
+		false,
+		nil,
+		[]string{"t1", "t2", "t4", "t5"},
+		[]DroppedTask{
+			{Name: "t3", Reason: "skipped by request"},
+		},
+		[]string{"t1", "t2", "t4", "t5"},
+	},
+	{
+		"Test with multiple skipped tasks should drop all of them",
+		"/job?skip=t4&skip=t5&skipPolicy=cascade",
+		false,
+		nil,
+		[]string{"t1", "t2", "t3"},
+		[]DroppedTask{
+			{Name: "t4", Reason: "skipped by request"},
+			{Name: "t5", Reason: "skipped by request"},
+		},
+		[]string{"t1", "t3", "t2"},
+	},
+	{
+		"Test with unknown skipped task should return specific error",
+		"/job?skip=t6",
+		true,
+		unknownTaskErr,
+		nil,
+		nil,
+		nil,
+	},
+	{
+		"Test with unknown skip policy should return specific error",
+		"/job?skip=t1&skipPolicy=keep",
+		true,
+		unsupportedSkipPolicyErr,
+		nil,
+		nil,
+		nil,
+	},
+}
+
+func TestSkipTasks(t *testing.T) {
+	for _, tt := range testSkipTasks {
+		t.Run(tt.name, func(t *testing.T) {
+			g := graph.NewGraph(len(selectionTasks))
+			if err := populateGraph(selectionTasks, g); err != nil {
+				t.Fatal(err)
+			}
+
+			j, sub, dropped, err := skipTasks(httptest.NewRequest("POST", tt.url, nil), Job{Tasks: selectionTasks}, Job{Tasks: selectionTasks}, g)
+			if tt.hasError {
+				assert.NotNil(t, err)
+				assert.True(t, errors.Is(err, tt.expectedError))
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expectedDropped, dropped)
+
+			names := make([]string, len(j.Tasks))
+			for i, task := range j.Tasks {
+				names[i] = task.Name
+			}
+			assert.Equal(t, tt.expectedTasks, names)
+
+			sorted, err := sub.StableTopologicalSort(graph.InputOrder)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expectedSort, sorted)
+		})
+	}
+}
+
+func TestSetDroppedHeader(t *testing.T) {
+	rr := httptest.NewRecorder()
+	err := setDroppedHeader(rr, []DroppedTask{{Name: "t1", Reason: "skipped by request"}})
+	assert.Nil(t, err)
+	assert.Equal(t, `[{"name":"t1","reason":"skipped by request"}]`, rr.Header().Get(DroppedTasksHeader))
+
+	rr = httptest.NewRecorder()
+	err = setDroppedHeader(rr, nil)
+	assert.Nil(t, err)
+	assert.Empty(t, rr.Header().Get(DroppedTasksHeader))
+}
+
+func TestSkipTasksOutsideTargets(t *testing.T) {
+	p, err := PlanJob(httptest.NewRequest("POST", "/job?target=t3&skip=t4&skip=t1&skipPolicy=ignore", nil), Job{Tasks: selectionTasks})
+	assert.Nil(t, err)
+	assert.Equal(t, []Command{{Name: "t3", Script: "c3"}}, p.Commands)
+	assert.Equal(t, []DroppedTask{
+		{Name: "t1", Reason: "skipped by request"},
+		{Name: "t4", Reason: "skipped by request"},
+	}, p.Dropped)
+
+	_, err = PlanJob(httptest.NewRequest("POST", "/job?target=t3&skip=t6", nil), Job{Tasks: selectionTasks})
+	assert.True(t, errors.Is(err, unknownTaskErr))
+}
@@ -0,0 +1,95 @@
+package job
+
+import (
+	"fmt"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// heredocDelimiter is the base of the here-document delimiters of the embedded commands
+const heredocDelimiter = "__TASK_EOF"
+
+// validateCommands parses the command of every task as bash script, so the commands which could not be embedded
+// in generated script (see Mode.EmbedsCommands) are rejected before the script is written. Returns *ValidationError
+// with problem per invalid command
+func validateCommands(j Job) error {
+	var problems []Problem
+	for i, t := range j.Tasks {
+		if err := validateCommand(t.Command); err != nil {
+			problems = append(problems, Problem{Pointer: taskPointer(i, "command"), Message: err.Error()})
+		}
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// validateCommand returns error when the script is not complete bash script, Ex: unbalanced quote or unterminated
+// here-document, or when it contains NUL byte which bash could not represent
+func validateCommand(script string) error {
+	if strings.ContainsRune(script, 0) {
+		return fmt.Errorf("command contains NUL byte which could not be embedded in shell script")
+	}
+	parser := syntax.NewParser(syntax.Variant(syntax.LangBash))
+	if _, err := parser.Parse(strings.NewReader(script), ""); err != nil {
+		return fmt.Errorf("command is not valid shell script: %s", err)
+	}
+	return nil
+}
+
+// embedCommand writes the script as quoted here-document which is evaluated in subshell, so the script is neither
+// expanded nor interpreted before it runs and exit, return or set +e of the script do not affect the generated
+// script. The subshell inherits the strict mode, its non zero exit code is the failure of the task. Its input is
+// /dev/null, as the script could be read from stdin (curl ... | bash), so the task could not read the rest of it.
+// The delimiter is unique for the script (see heredocDelimiter)
+func embedCommand(b *strings.Builder, script string) {
+	readCommand(b, script)
+	b.WriteString("( eval \"${__script}\" ) </dev/null\n")
+}
+
+// readCommand writes the script as quoted here-document which is read into __script variable
+func readCommand(b *strings.Builder, script string) {
+	delimiter := uniqueDelimiter(script)
+	// read returns non zero exit code at the end of the here-document
+	fmt.Fprintf(b, "IFS= read -r -d '' __script <<'%s' || true\n", delimiter)
+	b.WriteString(script + "\n")
+	b.WriteString(delimiter + "\n")
+}
+
+// writeTaskCommand embeds the command of the task, the command with retry policy is run with __retry
+// (see bashRetryPrelude) instead of being evaluated
+func writeTaskCommand(b *strings.Builder, c Command) {
+	if c.Retry.Attempts() <= 1 {
+		embedCommand(b, c.Script)
+		return
+	}
+	readCommand(b, c.Script)
+	delays, codes := retryArgs(c.Retry)
+	fmt.Fprintf(b, "__retry %s %s\n", shellQuote(delays), shellQuote(codes))
+}
+
+// hasRetry reports whether some of the commands is retried, the retry helper is written only then
+func hasRetry(commands []Command) bool {
+	for _, c := range commands {
+		if c.Retry.Attempts() > 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// uniqueDelimiter returns heredocDelimiter with numeric suffix when the script has line equal to it
+func uniqueDelimiter(script string) string {
+	lines := make(map[string]bool)
+	for _, line := range strings.Split(script, "\n") {
+		lines[line] = true
+	}
+
+	delimiter := heredocDelimiter
+	for i := 2; lines[delimiter]; i++ {
+		delimiter = fmt.Sprintf("%s_%d", heredocDelimiter, i)
+	}
+	return delimiter
+}
@@ -0,0 +1,56 @@
+package job
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testValidateCommand = []struct {
+	name     string
+	script   string
+	hasError bool
+}{
+	{"Test with simple command should be valid", "echo hello", false},
+	{"Test with multi line command should be valid", "if true; then\n  echo 'it''s'\nfi", false},
+	{"Test with here-document should be valid", "cat <<EOF\nhello\nEOF", false},
+	{"Test with unbalanced quote should be invalid", "echo 'hello", true},
+	{"Test with unterminated here-document should be invalid", "cat <<EOF\nhello", true},
+	{"Test with closing brace should be invalid", "echo hello\n}", true},
+	{"Test with NUL byte should be invalid", "echo hello\x00", true},
+}
+
+func TestValidateCommand(t *testing.T) {
+	for _, tt := range testValidateCommand {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateCommand(tt.script)
+			assert.Equal(t, tt.hasError, err != nil, err)
+		})
+	}
+}
+
+func TestUniqueDelimiter(t *testing.T) {
+	assert.Equal(t, "__TASK_EOF", uniqueDelimiter("echo __TASK_EOF"))
+	assert.Equal(t, "__TASK_EOF_3", uniqueDelimiter("echo\n__TASK_EOF\n__TASK_EOF_2"))
+}
+
+func TestHandleInvalidCommands(t *testing.T) {
+	body := `{"tasks":[{"name":"a","command":"echo a"},{"name":"b","command":"echo 'b","requires":["c"]}]}`
+
+	err := Handle(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/job?mode=bash", strings.NewReader(body)))
+	var validationErr *ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	pointers := make([]string, len(validationErr.Problems))
+	for i, p := range validationErr.Problems {
+		pointers[i] = p.Pointer
+	}
+	assert.Equal(t, []string{"/tasks/1/requires/0", "/tasks/1/command"}, pointers)
+
+	// json does not embed the commands
+	body = `{"tasks":[{"name":"a","command":"echo 'a"}]}`
+	err = Handle(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/job?mode=json", strings.NewReader(body)))
+	assert.Nil(t, err)
+}
@@ -0,0 +1,79 @@
+package job
+
+import (
+	"net/http"
+
+	"github.com/ivanspasov99/golang-api/pkg/config"
+)
+
+const (
+	tekton = "tekton"
+
+	tektonPipelineName = "job"
+)
+
+// tektonPipeline, tektonPipelineTask, etc. are the used subset of Tekton Pipelines v1 types
+type tektonPipeline struct {
+	k8sObject `json:",inline"`
+	Spec      tektonPipelineSpec `json:"spec"`
+}
+
+type tektonPipelineSpec struct {
+	Tasks []tektonPipelineTask `json:"tasks"`
+}
+
+type tektonPipelineTask struct {
+	Name     string         `json:"name"`
+	RunAfter []string       `json:"runAfter,omitempty"`
+	TaskSpec tektonEmbedded `json:"taskSpec"`
+}
+
+type tektonEmbedded struct {
+	Metadata k8sObjectMeta `json:"metadata"`
+	Steps    []tektonStep  `json:"steps"`
+}
+
+type tektonStep struct {
+	Name   string `json:"name"`
+	Image  string `json:"image"`
+	Script string `json:"script"`
+}
+
+// writeTekton writes Tekton Pipeline with embedded task per job task, the task step runs the command in the
+// configured image (see config.Config.Image) and the requires are the runAfter tasks. Task names are sanitized into
+// DNS labels (see dnsLabelIDs), the original name is kept in the embedded task annotations
+func writeTekton(w http.ResponseWriter, p *Plan) error {
+	conf := config.AppConfig()
+	image := taskImage(conf)
+	ids := sanitizeIDs(commandNames(p.Commands), dnsLabelIDs)
+	if err := setIDsHeader(w, ids); err != nil {
+		return err
+	}
+
+	tasks := make([]tektonPipelineTask, len(p.Commands))
+	for i, c := range p.Commands {
+		tasks[i] = tektonPipelineTask{
+			Name:     ids[c.Name],
+			RunAfter: requiredIDs(c, ids),
+			TaskSpec: tektonEmbedded{
+				Metadata: k8sObjectMeta{Annotations: map[string]string{k8sTaskNameAnnotation: c.Name}},
+				// script without shebang is run with sh by Tekton
+				Steps: []tektonStep{{Name: "run", Image: image, Script: c.Script}},
+			},
+		}
+	}
+
+	pipeline := tektonPipeline{
+		k8sObject: k8sObject{
+			APIVersion: "tekton.dev/v1",
+			Kind:       "Pipeline",
+			Metadata: k8sObjectMeta{
+				Name:      tektonPipelineName,
+				Namespace: conf.K8s.Namespace,
+				Labels:    map[string]string{k8sManagedByLabel: k8sManagedBy},
+			},
+		},
+		Spec: tektonPipelineSpec{Tasks: tasks},
+	}
+	return writeYAML(w, "apply with: kubectl apply -f - && tkn pipeline start job", p.Dropped, pipeline)
+}
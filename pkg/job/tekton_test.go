@@ -0,0 +1,23 @@
+package job
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ivanspasov99/golang-api/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteTekton(t *testing.T) {
+	if err := config.InitConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	err := writeTekton(rr, &exportPlan)
+	assert.Nil(t, err)
+	assertGolden(t, "tekton.golden", rr.Body.String())
+
+	err = writeTekton(ErrorResponseWriter{}, &Plan{})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,155 @@
+package job
+
+import (
+	"fmt"
+	"github.com/ivanspasov99/golang-api/pkg/graph"
+	"github.com/pkg/errors"
+	"reflect"
+	"strings"
+)
+
+var invalidJobErr = errors.New("invalid job")
+
+// Problem is single validation issue of the job
+type Problem struct {
+	// Pointer is JSON pointer (RFC 6901) to the offending field of the request, Ex: /tasks/3/requires/1
+	Pointer string `json:"pointer"`
+	Message string `json:"message"`
+}
+
+// ValidationError holds every problem found in the job. It matches invalidJobErr with errors.Is
+type ValidationError struct {
+	Problems []Problem
+	// Cycles lists every cycle between the tasks as ordered path, Ex: [a b a], every cycle is reported in Problems as well
+	Cycles [][]string
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		messages[i] = fmt.Sprintf("%s: %s", p.Pointer, p.Message)
+	}
+	return fmt.Sprintf("%s: %s", invalidJobErr, strings.Join(messages, "; "))
+}
+
+// Is makes errors.Is(err, invalidJobErr) work for ValidationError
+func (e *ValidationError) Is(target error) bool {
+	return target == invalidJobErr
+}
+
+// validateJob collects every problem of the job before it is sorted - empty names or commands, invalid retry
+// policies, duplicate task names, unknown requirements, self dependencies and cycles. When mergeDuplicates is set
+// identically named tasks with identical commands and retry policies are allowed as they will be merged (see mergeTasks)
+// Returns *ValidationError when there is at least one problem
+func validateJob(j Job, mergeDuplicates bool) error {
+	var problems []Problem
+
+	// cycles are searched in graph of the valid requirements only, so every problem is reported once
+	g := graph.NewGraph(len(j.Tasks))
+
+	// first index of every task name, duplicates point to the first definition
+	indexes := make(map[string]int, len(j.Tasks))
+	for i, t := range j.Tasks {
+		if t.Name == "" {
+			problems = append(problems, Problem{Pointer: taskPointer(i, "name"), Message: "task name is empty"})
+		} else if first, ok := indexes[t.Name]; ok {
+			switch {
+			case !mergeDuplicates:
+				problems = append(problems, Problem{
+					Pointer: taskPointer(i, "name"),
+					Message: fmt.Sprintf("duplicate task name %q, first defined at %s", t.Name, taskPointer(first, "name")),
+				})
+			case j.Tasks[first].Command != t.Command:
+				problems = append(problems, Problem{
+					Pointer: taskPointer(i, "command"),
+					Message: fmt.Sprintf("duplicate task name %q can not be merged as the command differs from %s", t.Name, taskPointer(first, "command")),
+				})
+			case !reflect.DeepEqual(j.Tasks[first].Retry, t.Retry):
+				problems = append(problems, Problem{
+					Pointer: taskPointer(i, "retry"),
+					Message: fmt.Sprintf("duplicate task name %q can not be merged as the retry differs from %s", t.Name, taskPointer(first, "retry")),
+				})
+			}
+		} else {
+			indexes[t.Name] = i
+			if err := g.AddVertex(t.Name); err != nil {
+				return err
+			}
+		}
+
+		if strings.TrimSpace(t.Command) == "" {
+			problems = append(problems, Problem{Pointer: taskPointer(i, "command"), Message: "task command is empty"})
+		}
+		problems = append(problems, validateRetry(i, t.Retry)...)
+	}
+
+	for i, t := range j.Tasks {
+		for k, r := range t.Required {
+			pointer := taskPointer(i, "requires", k)
+			switch {
+			case r == t.Name:
+				problems = append(problems, Problem{Pointer: pointer, Message: fmt.Sprintf("task %q requires itself", t.Name)})
+			case !g.HasVertex(r):
+				problems = append(problems, Problem{Pointer: pointer, Message: fmt.Sprintf("required task %q does not exist", r)})
+			default:
+				if err := g.AddEdge(&graph.Vertex{Name: t.Name}, &graph.Vertex{Name: r}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	cycles := g.Cycles()
+	for _, cycle := range cycles {
+		problems = append(problems, Problem{
+			Pointer: requirePointer(j, indexes, cycle[0], cycle[1]),
+			Message: fmt.Sprintf("tasks form cycle %s", strings.Join(cycle, " -> ")),
+		})
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems, Cycles: cycles}
+	}
+	return nil
+}
+
+// requirePointer returns pointer to the first requires entry of the task with name from which requires to. Every
+// definition of the task is searched, as the requires of the duplicates are merged with duplicates=merge
+func requirePointer(j Job, indexes map[string]int, from, to string) string {
+	for i, t := range j.Tasks {
+		if t.Name != from {
+			continue
+		}
+		for k, r := range t.Required {
+			if r == to {
+				return taskPointer(i, "requires", k)
+			}
+		}
+	}
+	return taskPointer(indexes[from])
+}
+
+// joinProblems returns single *ValidationError with the problems of both errors, they should be *ValidationError or nil
+func joinProblems(err, other error) error {
+	if err == nil {
+		return other
+	}
+	var first, second *ValidationError
+	if other == nil || !errors.As(err, &first) || !errors.As(other, &second) {
+		return err
+	}
+	return &ValidationError{
+		Problems: append(append([]Problem{}, first.Problems...), second.Problems...),
+		Cycles:   append(append([][]string{}, first.Cycles...), second.Cycles...),
+	}
+}
+
+// taskPointer returns JSON pointer to the task with index i followed by the path tokens
+func taskPointer(i int, path ...any) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "/tasks/%d", i)
+	for _, p := range path {
+		fmt.Fprintf(&b, "/%v", p)
+	}
+	return b.String()
+}
@@ -0,0 +1,164 @@
+package job
+
+import (
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+var testValidateJob = []struct {
+	name             string
+	job              Job
+	merge            bool
+	expectedProblems []Problem
+}{
+	{
+		"Test with valid job should return no problems",
+		Job{Tasks: []Task{
+			{Name: "t1", Command: "c1"},
+			{Name: "t2", Command: "c2", Required: []string{"t1"}},
+		}},
+		false,
+		nil,
+	},
+	{
+		"Test with empty job should return no problems",
+		Job{},
+		false,
+		nil,
+	},
+	{
+		"Test with empty name and command should return both problems",
+		Job{Tasks: []Task{
+			{Name: "t1", Command: "c1"},
+			{Name: "", Command: " "},
+		}},
+		false,
+		[]Problem{
+			{Pointer: "/tasks/1/name", Message: "task name is empty"},
+			{Pointer: "/tasks/1/command", Message: "task command is empty"},
+		},
+	},
+	{
+		"Test with duplicate names should point to the first definition",
+		Job{Tasks: []Task{
+			{Name: "t1", Command: "c1"},
+			{Name: "t2", Command: "c2"},
+			{Name: "t1", Command: "c3"},
+		}},
+		false,
+		[]Problem{
+			{Pointer: "/tasks/2/name", Message: `duplicate task name "t1", first defined at /tasks/0/name`},
+		},
+	},
+	{
+		"Test with duplicate names and merge should allow identical commands only",
+		Job{Tasks: []Task{
+			{Name: "t1", Command: "c1"},
+			{Name: "t1", Command: "c1", Required: []string{"t2"}},
+			{Name: "t2", Command: "c2"},
+			{Name: "t2", Command: "c3"},
+		}},
+		true,
+		[]Problem{
+			{Pointer: "/tasks/3/command", Message: `duplicate task name "t2" can not be merged as the command differs from /tasks/2/command`},
+		},
+	},
+	{
+		"Test with duplicate names and merge should allow identical retry policies only",
+		Job{Tasks: []Task{
+			{Name: "t1", Command: "c1", Retry: &Retry{MaxAttempts: 2}},
+			{Name: "t1", Command: "c1", Retry: &Retry{MaxAttempts: 2}},
+			{Name: "t1", Command: "c1"},
+		}},
+		true,
+		[]Problem{
+			{Pointer: "/tasks/2/retry", Message: `duplicate task name "t1" can not be merged as the retry differs from /tasks/0/retry`},
+		},
+	},
+	{
+		"Test with invalid retry policy should point to the field",
+		Job{Tasks: []Task{
+			{Name: "t1", Command: "c1", Retry: &Retry{MaxAttempts: 3, InitialDelay: "1 second"}},
+		}},
+		false,
+		[]Problem{
+			{Pointer: "/tasks/0/retry/initialDelay", Message: `invalid retry delay: time: unknown unit " second" in duration "1 second"`},
+		},
+	},
+	{
+		"Test with unknown requirements and self dependency should return every problem",
+		Job{Tasks: []Task{
+			{Name: "t1", Command: "c1", Required: []string{"t5"}},
+			{Name: "t2", Command: "c2", Required: []string{"t1", "t2", "t6"}},
+		}},
+		false,
+		[]Problem{
+			{Pointer: "/tasks/0/requires/0", Message: `required task "t5" does not exist`},
+			{Pointer: "/tasks/1/requires/1", Message: `task "t2" requires itself`},
+			{Pointer: "/tasks/1/requires/2", Message: `required task "t6" does not exist`},
+		},
+	},
+	{
+		"Test with cycles should point to the requirement which starts every cycle",
+		Job{Tasks: []Task{
+			{Name: "t1", Command: "c1", Required: []string{"t3", "t2"}},
+			{Name: "t2", Command: "c2", Required: []string{"t1"}},
+			{Name: "t3", Command: "c3"},
+			{Name: "t4", Command: "c4", Required: []string{"t5"}},
+			{Name: "t5", Command: "c5", Required: []string{"t6"}},
+			{Name: "t6", Command: "c6", Required: []string{"t4"}},
+		}},
+		false,
+		[]Problem{
+			{Pointer: "/tasks/0/requires/1", Message: "tasks form cycle t1 -> t2 -> t1"},
+			{Pointer: "/tasks/3/requires/0", Message: "tasks form cycle t4 -> t5 -> t6 -> t4"},
+		},
+	},
+	{
+		"Test with cycle closed by merged duplicate should point to its requirement",
+		Job{Tasks: []Task{
+			{Name: "a", Command: "c1"},
+			{Name: "b", Command: "c2", Required: []string{"a"}},
+			{Name: "a", Command: "c1", Required: []string{"b"}},
+		}},
+		true,
+		[]Problem{
+			{Pointer: "/tasks/2/requires/0", Message: "tasks form cycle a -> b -> a"},
+		},
+	},
+}
+
+func TestValidateJob(t *testing.T) {
+	for _, tt := range testValidateJob {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateJob(tt.job, tt.merge)
+			if tt.expectedProblems == nil {
+				assert.Nil(t, err)
+				return
+			}
+			assert.True(t, errors.Is(err, invalidJobErr))
+
+			var validationErr *ValidationError
+			assert.True(t, errors.As(err, &validationErr))
+			assert.Equal(t, tt.expectedProblems, validationErr.Problems)
+		})
+	}
+}
+
+func TestValidateJobShouldReturnCycles(t *testing.T) {
+	err := validateJob(Job{Tasks: []Task{
+		{Name: "t1", Command: "c1", Required: []string{"t2"}},
+		{Name: "t2", Command: "c2", Required: []string{"t1"}},
+		{Name: "t3", Command: ""},
+	}}, false)
+
+	var validationErr *ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, [][]string{{"t1", "t2", "t1"}}, validationErr.Cycles)
+
+	err = joinProblems(err, &ValidationError{Problems: []Problem{{Pointer: "/tasks/0/command", Message: "invalid"}}})
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Equal(t, [][]string{{"t1", "t2", "t1"}}, validationErr.Cycles)
+	assert.Len(t, validationErr.Problems, 3)
+}
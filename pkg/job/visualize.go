@@ -0,0 +1,294 @@
+package job
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/ivanspasov99/golang-api/pkg/graph"
+	"github.com/ivanspasov99/golang-api/pkg/logging"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+var unsupportedHighlightErr = errors.New("unsupported highlight")
+
+const (
+	dot      = "dot"
+	mermaid  = "mermaid"
+	htmlMode = "html"
+
+	highlightQuery  = "highlight"
+	highlightOrder  = "order"
+	highlightLevels = "levels"
+	highlightCycles = "cycles"
+
+	// highlightColor is used for the cycle vertices and edges
+	highlightColor = "#d00000"
+)
+
+// Highlight defines what is shown in the graph modes (dot, mermaid, html) besides the tasks and their requires
+type Highlight struct {
+	// Order prefixes the task labels with their position in Plan.Commands
+	Order bool
+	// Levels groups the tasks of every level (see Plan.Levels) into subgraph
+	Levels bool
+	// Cycles colors the tasks and the requires of Plan.Cycles
+	Cycles bool
+}
+
+// htmlTemplate is the page of the html mode, it embeds the Mermaid source and does not load any script, so the page is
+// self-contained and it is shown offline as well
+var htmlTemplate = template.Must(template.New(htmlMode).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>job</title>
+</head>
+<body>
+<pre class="mermaid">
+{{.}}</pre>
+</body>
+</html>
+`))
+
+// graphView is the plan graph prepared for drawing. The edges point from the required task to the task,
+// so they follow the execution order
+type graphView struct {
+	nodes      []string
+	edges      []graph.Edge[string]
+	order      map[string]int
+	levels     [][]string
+	cycleNodes map[string]bool
+	cycleEdges map[graph.Edge[string]]bool
+}
+
+func newGraphView(p *Plan) graphView {
+	v := graphView{cycleNodes: map[string]bool{}, cycleEdges: map[graph.Edge[string]]bool{}}
+	if p.Graph != nil {
+		v.nodes = p.Graph.Keys()
+		for _, e := range p.Graph.Edges() {
+			v.edges = append(v.edges, graph.Edge[string]{From: e.To, To: e.From})
+		}
+	}
+
+	if p.Highlight.Order {
+		v.order = make(map[string]int, len(p.Commands))
+		for i, c := range p.Commands {
+			v.order[c.Name] = i + 1
+		}
+	}
+	if p.Highlight.Levels {
+		v.levels = make([][]string, len(p.Levels))
+		for i, level := range p.Levels {
+			v.levels[i] = commandNames(level)
+		}
+	}
+	if p.Highlight.Cycles {
+		for _, cycle := range p.Cycles {
+			for i := 0; i < len(cycle)-1; i++ {
+				v.cycleNodes[cycle[i]] = true
+				v.cycleEdges[graph.Edge[string]{From: cycle[i+1], To: cycle[i]}] = true
+			}
+		}
+	}
+	return v
+}
+
+// label returns the name of the task, prefixed with its position when the order is highlighted
+func (v graphView) label(name string) string {
+	if n, ok := v.order[name]; ok {
+		return fmt.Sprintf("%d. %s", n, name)
+	}
+	return name
+}
+
+// writeDot writes the graph in Graphviz DOT language, Ex: dot -Tsvg
+func writeDot(w http.ResponseWriter, p *Plan) error {
+	v := newGraphView(p)
+
+	var b strings.Builder
+	b.WriteString("digraph job {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box];\n")
+	for _, d := range p.Dropped {
+		fmt.Fprintf(&b, "  // dropped %q: %q\n", d.Name, d.Reason)
+	}
+
+	node := func(indent, name string) {
+		fmt.Fprintf(&b, "%s%s [label=%s", indent, dotQuote(name), dotQuote(v.label(name)))
+		if v.cycleNodes[name] {
+			fmt.Fprintf(&b, ", color=%s", dotQuote(highlightColor))
+		}
+		b.WriteString("];\n")
+	}
+
+	grouped := make(map[string]bool, len(v.nodes))
+	for i, level := range v.levels {
+		fmt.Fprintf(&b, "  subgraph cluster_level_%d {\n", i+1)
+		fmt.Fprintf(&b, "    label=%s;\n", dotQuote(fmt.Sprintf("level %d", i+1)))
+		for _, name := range level {
+			node("    ", name)
+			grouped[name] = true
+		}
+		b.WriteString("  }\n")
+	}
+	for _, name := range v.nodes {
+		if !grouped[name] {
+			node("  ", name)
+		}
+	}
+
+	for _, e := range v.edges {
+		fmt.Fprintf(&b, "  %s -> %s", dotQuote(e.From), dotQuote(e.To))
+		if v.cycleEdges[e] {
+			fmt.Fprintf(&b, " [color=%s]", dotQuote(highlightColor))
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+	return writeText(w, b.String())
+}
+
+// dotQuote quotes s as DOT string, the quotes, backslashes and new lines are escaped
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// writeMermaid writes the graph as Mermaid flowchart
+func writeMermaid(w http.ResponseWriter, p *Plan) error {
+	return writeText(w, mermaidFlowchart(p))
+}
+
+// writeHTML writes self-contained HTML page which embeds the Mermaid flowchart source
+func writeHTML(w http.ResponseWriter, p *Plan) error {
+	var b strings.Builder
+	if err := htmlTemplate.Execute(&b, mermaidFlowchart(p)); err != nil {
+		return err
+	}
+	return writeText(w, b.String())
+}
+
+// mermaidFlowchart returns the Mermaid source of the plan graph. The tasks get generated ids (n1, n2, etc.), as
+// Mermaid ids could not contain most of the characters and some words (Ex: end) are reserved
+func mermaidFlowchart(p *Plan) string {
+	v := newGraphView(p)
+	ids := make(map[string]string, len(v.nodes))
+	for i, name := range v.nodes {
+		ids[name] = fmt.Sprintf("n%d", i+1)
+	}
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+	for _, d := range p.Dropped {
+		fmt.Fprintf(&b, "  %%%% dropped %q: %q\n", d.Name, d.Reason)
+	}
+
+	node := func(indent, name string) {
+		fmt.Fprintf(&b, "%s%s[\"%s\"]\n", indent, ids[name], mermaidEscape(v.label(name)))
+	}
+
+	grouped := make(map[string]bool, len(v.nodes))
+	for i, level := range v.levels {
+		fmt.Fprintf(&b, "  subgraph level_%d[\"level %d\"]\n", i+1, i+1)
+		for _, name := range level {
+			node("    ", name)
+			grouped[name] = true
+		}
+		b.WriteString("  end\n")
+	}
+	for _, name := range v.nodes {
+		if !grouped[name] {
+			node("  ", name)
+		}
+	}
+
+	var cycleEdges []string
+	for i, e := range v.edges {
+		fmt.Fprintf(&b, "  %s --> %s\n", ids[e.From], ids[e.To])
+		if v.cycleEdges[e] {
+			cycleEdges = append(cycleEdges, fmt.Sprint(i))
+		}
+	}
+
+	var cycleNodes []string
+	for _, name := range v.nodes {
+		if v.cycleNodes[name] {
+			cycleNodes = append(cycleNodes, ids[name])
+		}
+	}
+	if len(cycleNodes) > 0 {
+		fmt.Fprintf(&b, "  classDef cycle stroke:%s,stroke-width:2px\n", highlightColor)
+		fmt.Fprintf(&b, "  class %s cycle\n", strings.Join(cycleNodes, ","))
+	}
+	if len(cycleEdges) > 0 {
+		fmt.Fprintf(&b, "  linkStyle %s stroke:%s,stroke-width:2px\n", strings.Join(cycleEdges, ","), highlightColor)
+	}
+	return b.String()
+}
+
+// mermaidEscape escapes the label characters which end the label or start entity code
+func mermaidEscape(s string) string {
+	return strings.NewReplacer("#", "#35;", `"`, "#quot;", "\n", " ").Replace(s)
+}
+
+// parseHighlight returns the query highlight, which could be repeated or comma separated list of order, levels and cycles
+// The cycles are highlighted when it is not set. Returns unsupportedHighlightErr
+func parseHighlight(r *http.Request) (Highlight, error) {
+	values, ok := r.URL.Query()[highlightQuery]
+	if !ok {
+		return Highlight{Cycles: true}, nil
+	}
+
+	var h Highlight
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			switch strings.ToLower(strings.TrimSpace(item)) {
+			case highlightOrder:
+				h.Order = true
+			case highlightLevels:
+				h.Levels = true
+			case highlightCycles:
+				h.Cycles = true
+			case "":
+			default:
+				return Highlight{}, fmt.Errorf("%w: %s, supported are %s, %s, %s", unsupportedHighlightErr, item,
+					highlightOrder, highlightLevels, highlightCycles)
+			}
+		}
+	}
+	return h, nil
+}
+
+// writeCyclicGraph draws with the mode (see Mode.DrawsCycles) the job which has failed the validation when it has cycles, so they could be found in
+// the picture. Tasks are added once, requires of unknown tasks are left out. Returns validationErr when the job
+// has no cycles
+func writeCyclicGraph(w http.ResponseWriter, r *http.Request, m Mode, j Job, validationErr error) error {
+	g := graph.NewGraph(len(j.Tasks))
+	for _, t := range j.Tasks {
+		if t.Name != "" && !g.HasVertex(t.Name) {
+			// the vertex does not exist, so there is no error
+			_ = g.AddVertex(t.Name)
+		}
+	}
+	for _, t := range j.Tasks {
+		for _, required := range t.Required {
+			if g.HasVertex(t.Name) && g.HasVertex(required) {
+				_ = g.DirectedGraph.AddEdge(t.Name, required)
+			}
+		}
+	}
+
+	cycles := g.Cycles()
+	if len(cycles) == 0 {
+		return validationErr
+	}
+
+	highlight, err := parseHighlight(r)
+	if err != nil {
+		return err
+	}
+	logging.Println(r.Context(), zerolog.InfoLevel, "Job with cycles is drawn")
+	return writePlan(w, m, &Plan{Graph: g, Cycles: cycles, Highlight: highlight})
+}
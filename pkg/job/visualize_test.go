@@ -0,0 +1,135 @@
+package job
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ivanspasov99/golang-api/pkg/graph"
+	"github.com/stretchr/testify/assert"
+)
+
+// exportGraph returns the graph of exportPlan
+func exportGraph(t *testing.T) *graph.NamedGraph {
+	g := graph.NewGraph(len(exportCommands))
+	for _, c := range exportCommands {
+		if err := g.AddVertex(c.Name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, c := range exportCommands {
+		for _, r := range c.Requires {
+			if err := g.DirectedGraph.AddEdge(c.Name, r); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return g
+}
+
+var cyclicJob = Job{Tasks: []Task{
+	{Name: "a", Command: "echo a", Required: []string{"c"}},
+	{Name: "b", Command: "echo b", Required: []string{"a"}},
+	{Name: "c", Command: "echo c", Required: []string{"b", "unknown"}},
+	{Name: "d \"quoted\"", Command: "echo d", Required: []string{"a"}},
+}}
+
+var testWriteGraph = []struct {
+	name      string
+	writer    ResponseWriter
+	highlight Highlight
+	golden    string
+}{
+	{"Test dot without highlight should write tasks and requires", writeDot, Highlight{}, "dot.golden"},
+	{"Test dot with order and levels should label and group tasks", writeDot, Highlight{Order: true, Levels: true}, "dot-highlight.golden"},
+	{"Test mermaid without highlight should write tasks and requires", writeMermaid, Highlight{}, "mermaid.golden"},
+	{"Test mermaid with order and levels should label and group tasks", writeMermaid, Highlight{Order: true, Levels: true}, "mermaid-highlight.golden"},
+	{"Test html should embed mermaid source", writeHTML, Highlight{Order: true}, "html.golden"},
+}
+
+func TestWriteGraph(t *testing.T) {
+	for _, tt := range testWriteGraph {
+		t.Run(tt.name, func(t *testing.T) {
+			p := exportPlan
+			p.Graph = exportGraph(t)
+			p.Highlight = tt.highlight
+
+			rr := httptest.NewRecorder()
+			err := tt.writer(rr, &p)
+			assert.Nil(t, err)
+			assertGolden(t, tt.golden, rr.Body.String())
+
+			err = tt.writer(ErrorResponseWriter{}, &p)
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+var testWriteCyclicGraph = []struct {
+	name   string
+	url    string
+	golden string
+}{
+	{"Test dot should highlight cycle", "/job?mode=dot", "dot-cycle.golden"},
+	{"Test mermaid should highlight cycle", "/job?mode=mermaid", "mermaid-cycle.golden"},
+	{"Test html should highlight cycle", "/job?mode=html", "html-cycle.golden"},
+}
+
+func TestWriteCyclicGraph(t *testing.T) {
+	for _, tt := range testWriteCyclicGraph {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, tt.url, nil)
+			m, err := lookupMode(r)
+			assert.Nil(t, err)
+
+			rr := httptest.NewRecorder()
+			err = writeCyclicGraph(rr, r, m, cyclicJob, invalidJobErr)
+			assert.Nil(t, err)
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, m.ContentType, rr.Header().Get("Content-Type"))
+			assertGolden(t, tt.golden, rr.Body.String())
+		})
+	}
+
+	t.Run("Test job without cycles should return the validation error", func(t *testing.T) {
+		j := Job{Tasks: []Task{{Name: "a", Command: "echo a", Required: []string{"unknown"}}}}
+		m, _ := modes.Lookup(dot)
+		err := writeCyclicGraph(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/job", nil), m, j, invalidJobErr)
+		assert.ErrorIs(t, err, invalidJobErr)
+	})
+}
+
+func TestHandleGraphModeWithCycle(t *testing.T) {
+	body := `{"tasks":[{"name":"a","command":"echo a","requires":["b"]},{"name":"b","command":"echo b","requires":["a"]}]}`
+
+	rr := httptest.NewRecorder()
+	err := Handle(rr, httptest.NewRequest(http.MethodPost, "/job?mode=dot", strings.NewReader(body)))
+	assert.Nil(t, err)
+	assert.Contains(t, rr.Body.String(), `"a" -> "b" [color="#d00000"];`)
+
+	err = Handle(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/job?mode=json", strings.NewReader(body)))
+	assert.ErrorIs(t, err, invalidJobErr)
+}
+
+var testParseHighlight = []struct {
+	name              string
+	url               string
+	expectedHighlight Highlight
+	expectedErr       error
+}{
+	{"Test without highlight should highlight cycles", "/job", Highlight{Cycles: true}, nil},
+	{"Test with empty highlight should highlight nothing", "/job?highlight=", Highlight{}, nil},
+	{"Test with list should highlight every item", "/job?highlight=order,Levels&highlight=cycles", Highlight{Order: true, Levels: true, Cycles: true}, nil},
+	{"Test with unsupported item should return error", "/job?highlight=order,colors", Highlight{}, unsupportedHighlightErr},
+}
+
+func TestParseHighlight(t *testing.T) {
+	for _, tt := range testParseHighlight {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := parseHighlight(httptest.NewRequest(http.MethodPost, tt.url, nil))
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.Equal(t, tt.expectedHighlight, h)
+		})
+	}
+}
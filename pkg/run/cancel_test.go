@@ -0,0 +1,135 @@
+package run
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ivanspasov99/golang-api/pkg/job"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCancelStopsRunningAndCancelsPendingTasks(t *testing.T) {
+	runner := newBlockingRunner("a", "b", "c", "d")
+	m := NewManager(runner, Options{})
+	r := m.Start(context.Background(), "job", job.Plan{Parallelism: 2, Commands: []job.Command{
+		command("a"), command("b"), command("c", "a"), command("d"),
+	}})
+	assert.Equal(t, set("a", "b"), runner.expectStartedSet(t, 2))
+	runner.release["b"] <- 0
+	assert.Equal(t, "d", runner.expectStarted(t))
+
+	at := time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+	_, err := m.Cancel(r.ID(), Cancellation{By: "alice", At: at})
+	assert.NoError(t, err)
+	// the second cancellation does not replace the first one
+	_, _ = m.Cancel(r.ID(), Cancellation{By: "bob"})
+
+	s := waitRun(t, r)
+	assert.Equal(t, Cancelled, s.State)
+	assert.Equal(t, &Cancellation{By: "alice", At: at}, s.Cancellation)
+	assert.Equal(t, map[string]State{"a": Cancelled, "b": Succeeded, "c": Cancelled, "d": Cancelled}, taskStates(s))
+	assert.Equal(t, -1, *s.Tasks[0].ExitCode)
+	assert.Nil(t, s.Tasks[2].StartedAt)
+
+	_, err = m.Cancel(r.ID(), Cancellation{By: "alice"})
+	assert.ErrorIs(t, err, runFinishedErr)
+}
+
+func TestCancelThroughContext(t *testing.T) {
+	runner := newBlockingRunner("a", "b")
+	m := NewManager(runner, Options{})
+	ctx, cancel := context.WithCancel(context.Background())
+	r := m.Start(ctx, "job", job.Plan{Commands: []job.Command{command("a"), command("b", "a")}})
+	runner.expectStarted(t)
+
+	cancel()
+	s := waitRun(t, r)
+	assert.Equal(t, Cancelled, s.State)
+	assert.Equal(t, context.Canceled.Error(), s.Cancellation.By)
+	assert.Equal(t, map[string]State{"a": Cancelled, "b": Cancelled}, taskStates(s))
+}
+
+func TestCancelTaskWaitingForSlot(t *testing.T) {
+	runner := newBlockingRunner("a", "b")
+	m := NewManager(runner, Options{MaxParallelism: 1})
+	first := m.Start(context.Background(), "first", job.Plan{Commands: []job.Command{command("a")}})
+	runner.expectStarted(t)
+	second := m.Start(context.Background(), "second", job.Plan{Commands: []job.Command{command("b")}})
+
+	_, err := m.Cancel(second.ID(), Cancellation{By: "alice"})
+	assert.NoError(t, err)
+	s := waitRun(t, second)
+	assert.Equal(t, Cancelled, s.State)
+	assert.Equal(t, Cancelled, s.Tasks[0].State)
+	assert.Nil(t, s.Tasks[0].StartedAt)
+
+	runner.release["a"] <- 0
+	assert.Equal(t, Succeeded, waitRun(t, first).State)
+}
+
+func TestCancelFinishedRunKeepsResult(t *testing.T) {
+	m := NewManager(exitCodes(nil), Options{})
+	r := m.Start(context.Background(), "job", job.Plan{Commands: []job.Command{command("a")}})
+	waitRun(t, r)
+
+	_, err := m.Cancel(r.ID(), Cancellation{By: "alice"})
+	assert.ErrorIs(t, err, runFinishedErr)
+	s := r.Status()
+	assert.Equal(t, Succeeded, s.State)
+	assert.Nil(t, s.Cancellation)
+}
+
+func TestShutdownCancelsRuns(t *testing.T) {
+	runner := newBlockingRunner("a", "b")
+	m := NewManager(runner, Options{})
+	first := m.Start(context.Background(), "first", job.Plan{Commands: []job.Command{command("a")}})
+	second := m.Start(context.Background(), "second", job.Plan{Commands: []job.Command{command("b")}})
+	runner.expectStartedSet(t, 2)
+
+	assert.NoError(t, m.Shutdown(context.Background()))
+	for _, r := range []*Run{first, second} {
+		s := r.Status()
+		assert.Equal(t, Cancelled, s.State)
+		assert.Equal(t, shutdownBy, s.Cancellation.By)
+	}
+}
+
+func TestHandleCancel(t *testing.T) {
+	runner := newBlockingRunner("a")
+	m := NewManager(runner, Options{})
+	r := m.Start(context.Background(), "job", job.Plan{Commands: []job.Command{command("a")}})
+	runner.expectStarted(t)
+
+	req := httptest.NewRequest(http.MethodDelete, "/runs/"+r.ID(), nil)
+	req.Header.Set(userHeader, "alice")
+	rr := httptest.NewRecorder()
+	HandleError(m.HandleRuns).ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusAccepted, rr.Code)
+
+	var s Status
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &s))
+	assert.Equal(t, "alice", s.Cancellation.By)
+	assert.Equal(t, Cancelled, waitRun(t, r).State)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+	}{
+		{name: "finished run", method: http.MethodDelete, path: "/runs/" + r.ID(), status: http.StatusConflict},
+		{name: "unknown run", method: http.MethodDelete, path: "/runs/unknown", status: http.StatusNotFound},
+		{name: "logs", method: http.MethodDelete, path: "/runs/" + r.ID() + "/logs", status: http.StatusMethodNotAllowed},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			HandleError(m.HandleRuns).ServeHTTP(rr, httptest.NewRequest(test.method, test.path, nil))
+			assert.Equal(t, test.status, rr.Code)
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/ivanspasov99/golang-api/pkg/job"
+	"github.com/ivanspasov99/golang-api/pkg/logging"
+	"github.com/rs/zerolog"
+)
+
+// taskResult is sent by the task goroutine to the scheduler when the command has finished
+type taskResult struct {
+	index int
+	// started is false when the run has been cancelled while the task was waiting for free slot
+	started  bool
+	exitCode int
+	err      error
+}
+
+// schedule keeps the dependency state of the commands of the run, it is used only by the scheduler goroutine
+type schedule struct {
+	commands []job.Command
+	// remaining is the number of required commands of every command which have not succeeded yet
+	remaining []int
+	// dependents are the indexes of the commands which require the command
+	dependents [][]int
+	// ready are the indexes of the commands which could be started, ordered by the plan
+	ready []int
+}
+
+func newSchedule(commands []job.Command) *schedule {
+	s := &schedule{
+		commands:   commands,
+		remaining:  make([]int, len(commands)),
+		dependents: make([][]int, len(commands)),
+	}
+	index := make(map[string]int, len(commands))
+	for i, c := range commands {
+		index[c.Name] = i
+	}
+	for i, c := range commands {
+		s.remaining[i] = len(c.Requires)
+		for _, required := range c.Requires {
+			s.dependents[index[required]] = append(s.dependents[index[required]], i)
+		}
+		if s.remaining[i] == 0 {
+			s.ready = append(s.ready, i)
+		}
+	}
+	return s
+}
+
+// next removes and returns the first ready command
+func (s *schedule) next() int {
+	i := s.ready[0]
+	s.ready = s.ready[1:]
+	return i
+}
+
+// succeed makes ready the dependents which required commands have all succeeded
+func (s *schedule) succeed(i int) {
+	for _, d := range s.dependents[i] {
+		s.remaining[d]--
+		if s.remaining[d] == 0 {
+			s.ready = append(s.ready, d)
+		}
+	}
+	// the commands are started in the planned order, so the order of the run does not depend on the timing
+	sort.Ints(s.ready)
+}
+
+// fail returns every command which transitively requires the failed command, they never become ready
+func (s *schedule) fail(i int) []int {
+	var skipped []int
+	visited := make(map[int]bool)
+	stack := append([]int(nil), s.dependents[i]...)
+	for len(stack) > 0 {
+		d := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if visited[d] {
+			continue
+		}
+		visited[d] = true
+		skipped = append(skipped, d)
+		stack = append(stack, s.dependents[d]...)
+	}
+	sort.Ints(skipped)
+	return skipped
+}
+
+// execute runs the commands of the run as the dependency graph allows. The command is started once all its
+// required commands have succeeded, so the failing command skips only the commands which require it and the
+// independent ones are still executed. At most r.parallelism commands of the run and len(m.slots) commands of all
+// runs are running at the same time. The scheduler is the only goroutine which changes the schedule, the task
+// goroutines send their results back over channel
+// When ctx is done no more commands are started, the running ones are stopped by the runner and the rest are
+// cancelled
+func (m *Manager) execute(ctx context.Context, r *Run) {
+	logging.Println(ctx, zerolog.InfoLevel, fmt.Sprintf("Run %s has started", r.ID()))
+	r.start(m.now())
+
+	s := newSchedule(r.commands)
+	results := make(chan taskResult)
+	result := Succeeded
+	cancelled := false
+	running := 0
+	for {
+		for len(s.ready) > 0 && ctx.Err() == nil && (r.parallelism == 0 || running < r.parallelism) {
+			go m.executeTask(ctx, r, s.next(), results)
+			running++
+		}
+		if running == 0 {
+			break
+		}
+
+		res := <-results
+		running--
+		c := r.commands[res.index]
+		if !res.started {
+			// the task stays pending and it is cancelled with the rest
+			continue
+		}
+		switch r.finishTask(c.Name, res.exitCode, res.err, ctx.Err() != nil, m.now()) {
+		case Succeeded:
+			s.succeed(res.index)
+		case Cancelled:
+			cancelled = true
+			logging.Println(ctx, zerolog.InfoLevel, fmt.Sprintf("Task %s of run %s has been stopped", c.Name, r.ID()))
+		default:
+			result = Failed
+			logging.Println(ctx, zerolog.ErrorLevel, fmt.Sprintf("Task %s of run %s has failed, exit code %d", c.Name, r.ID(), res.exitCode))
+			for _, d := range s.fail(res.index) {
+				r.skipTask(r.commands[d].Name)
+			}
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		// the context has been cancelled by the caller of Start, Cancel records the cancellation before
+		_ = r.requestCancel(Cancellation{By: err.Error(), At: m.now()})
+	}
+	if r.cancelPending() || cancelled {
+		result = Cancelled
+	}
+	r.finish(result, m.now())
+	logging.Println(ctx, zerolog.InfoLevel, fmt.Sprintf("Run %s has finished: %s", r.ID(), result))
+}
+
+// executeTask waits for free process slot and runs the command, the task is pending until then
+// The failing command is executed again as its retry policy allows (see job.Retry), every attempt is logged and
+// recorded in the run status. The process slot is kept during the delays. The output of the command is kept in the run logs
+func (m *Manager) executeTask(ctx context.Context, r *Run, i int, results chan<- taskResult) {
+	if m.slots != nil {
+		select {
+		case m.slots <- struct{}{}:
+			defer func() { <-m.slots }()
+		case <-ctx.Done():
+			results <- taskResult{index: i}
+			return
+		}
+	}
+
+	c := r.commands[i]
+	r.startTask(c.Name, m.now())
+	stdout := &lineWriter{buf: r.logs, task: c.Name, stream: Stdout, now: m.now}
+	stderr := &lineWriter{buf: r.logs, task: c.Name, stream: Stderr, now: m.now}
+	for attempt := 1; ; attempt++ {
+		stdout.attempt, stderr.attempt = attempt, attempt
+		a := Attempt{Number: attempt, StartedAt: m.now()}
+		exitCode, err := m.runner.Run(ctx, c, stdout, stderr)
+		stdout.Flush()
+		stderr.Flush()
+		a.FinishedAt = m.now()
+		if err != nil {
+			a.Error = err.Error()
+		} else {
+			a.ExitCode = &exitCode
+		}
+		r.finishAttempt(c.Name, a)
+
+		level := zerolog.InfoLevel
+		if exitCode != 0 || err != nil {
+			level = zerolog.WarnLevel
+		}
+		logging.Println(ctx, level, fmt.Sprintf("Task %s of run %s attempt %d/%d has exited with code %d",
+			c.Name, r.ID(), attempt, c.Retry.Attempts(), exitCode))
+
+		retry := err == nil && ctx.Err() == nil && attempt < c.Retry.Attempts() && c.Retry.Retries(exitCode)
+		if retry {
+			delay := c.Retry.Delay(attempt)
+			logging.Println(ctx, zerolog.InfoLevel, fmt.Sprintf("Task %s of run %s is retried in %s", c.Name, r.ID(), delay))
+			select {
+			case <-m.after(delay):
+				continue
+			case <-ctx.Done():
+			}
+		}
+		results <- taskResult{index: i, started: true, exitCode: exitCode, err: err}
+		return
+	}
+}
@@ -0,0 +1,209 @@
+package run
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ivanspasov99/golang-api/pkg/job"
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingRunner is fake runner which reports every started command and blocks it until the test releases it
+// with exit code or the run is cancelled, so the tests decide the order in which the commands finish
+type blockingRunner struct {
+	started chan string
+	release map[string]chan int
+}
+
+func newBlockingRunner(names ...string) *blockingRunner {
+	b := &blockingRunner{started: make(chan string, len(names)), release: make(map[string]chan int, len(names))}
+	for _, name := range names {
+		b.release[name] = make(chan int)
+	}
+	return b
+}
+
+// Run exits with -1 when ctx is done as ShellRunner does
+func (b *blockingRunner) Run(ctx context.Context, c job.Command, stdout, stderr io.Writer) (int, error) {
+	b.started <- c.Name
+	select {
+	case code := <-b.release[c.Name]:
+		return code, nil
+	case <-ctx.Done():
+		return -1, nil
+	}
+}
+
+// expectStarted receives the next started command
+func (b *blockingRunner) expectStarted(t *testing.T) string {
+	t.Helper()
+	select {
+	case name := <-b.started:
+		return name
+	case <-time.After(5 * time.Second):
+		t.Fatal("no command has been started")
+		return ""
+	}
+}
+
+// expectStartedSet receives n started commands, the commands started at once are received in any order
+func (b *blockingRunner) expectStartedSet(t *testing.T, n int) map[string]bool {
+	t.Helper()
+	names := make(map[string]bool, n)
+	for i := 0; i < n; i++ {
+		names[b.expectStarted(t)] = true
+	}
+	return names
+}
+
+func command(name string, requires ...string) job.Command {
+	return job.Command{Name: name, Script: name, Requires: requires}
+}
+
+func waitRun(t *testing.T, r *Run) Status {
+	t.Helper()
+	select {
+	case <-r.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("run %s has not finished", r.ID())
+	}
+	return r.Status()
+}
+
+func set(names ...string) map[string]bool {
+	s := make(map[string]bool, len(names))
+	for _, name := range names {
+		s[name] = true
+	}
+	return s
+}
+
+func TestExecuteStartsTaskAfterItsRequires(t *testing.T) {
+	runner := newBlockingRunner("a", "b", "c", "d")
+	m := NewManager(runner, Options{})
+	// d requires b and c which require a
+	r := m.Start(context.Background(), "diamond", job.Plan{Commands: []job.Command{
+		command("a"), command("b", "a"), command("c", "a"), command("d", "b", "c"),
+	}})
+
+	assert.Equal(t, "a", runner.expectStarted(t))
+	runner.release["a"] <- 0
+	assert.Equal(t, set("b", "c"), runner.expectStartedSet(t, 2))
+
+	runner.release["c"] <- 0
+	assert.Eventually(t, func() bool {
+		return taskStates(r.Status())["c"] == Succeeded
+	}, 5*time.Second, time.Millisecond)
+	// d still waits for b
+	assert.Equal(t, map[string]State{"a": Succeeded, "b": Running, "c": Succeeded, "d": Pending}, taskStates(r.Status()))
+
+	runner.release["b"] <- 0
+	assert.Equal(t, "d", runner.expectStarted(t))
+	runner.release["d"] <- 0
+
+	s := waitRun(t, r)
+	assert.Equal(t, Succeeded, s.State)
+}
+
+func TestExecuteSkipsOnlyDependentsOfFailedTask(t *testing.T) {
+	m := NewManager(exitCodes(map[string]int{"a": 1}), Options{})
+	r := m.Start(context.Background(), "job", job.Plan{Commands: []job.Command{
+		command("a"), command("x"), command("b", "a"), command("y", "x"), command("c", "b", "y"),
+	}})
+
+	s := waitRun(t, r)
+	assert.Equal(t, Failed, s.State)
+	assert.Equal(t, map[string]State{"a": Failed, "x": Succeeded, "b": Skipped, "y": Succeeded, "c": Skipped}, taskStates(s))
+}
+
+func TestExecuteLimitsRunParallelism(t *testing.T) {
+	runner := newBlockingRunner("t1", "t2", "t3", "t4")
+	m := NewManager(runner, Options{})
+	r := m.Start(context.Background(), "job", job.Plan{Parallelism: 2, Commands: []job.Command{
+		command("t1"), command("t2"), command("t3"), command("t4"),
+	}})
+
+	// the ready tasks are started in the planned order
+	assert.Equal(t, set("t1", "t2"), runner.expectStartedSet(t, 2))
+	assert.Equal(t, map[string]State{"t1": Running, "t2": Running, "t3": Pending, "t4": Pending}, taskStates(r.Status()))
+
+	runner.release["t2"] <- 0
+	assert.Equal(t, "t3", runner.expectStarted(t))
+	runner.release["t1"] <- 0
+	assert.Equal(t, "t4", runner.expectStarted(t))
+	runner.release["t3"] <- 0
+	runner.release["t4"] <- 0
+
+	s := waitRun(t, r)
+	assert.Equal(t, Succeeded, s.State)
+}
+
+func TestExecuteLimitsProcessParallelism(t *testing.T) {
+	runner := newBlockingRunner("a1", "a2", "b1")
+	m := NewManager(runner, Options{MaxParallelism: 2})
+	first := m.Start(context.Background(), "a", job.Plan{Commands: []job.Command{command("a1"), command("a2")}})
+	assert.Equal(t, set("a1", "a2"), runner.expectStartedSet(t, 2))
+
+	second := m.Start(context.Background(), "b", job.Plan{Commands: []job.Command{command("b1")}})
+	// b1 waits for free slot
+	runner.release["a1"] <- 0
+	assert.Equal(t, "b1", runner.expectStarted(t))
+	runner.release["a2"] <- 0
+	runner.release["b1"] <- 0
+
+	assert.Equal(t, Succeeded, waitRun(t, first).State)
+	assert.Equal(t, Succeeded, waitRun(t, second).State)
+}
+
+func TestExecuteRespectsParallelismUnderLoad(t *testing.T) {
+	const tasks = 50
+	var (
+		mu             sync.Mutex
+		running, peak  int
+		finished       = make(map[string]bool)
+		startedEarlier = make(map[string]bool)
+	)
+	commands := make([]job.Command, tasks)
+	for i := range commands {
+		name := string(rune('A' + i))
+		commands[i] = command(name)
+		// every task requires the task two positions before it
+		if i >= 2 {
+			commands[i].Requires = []string{commands[i-2].Name}
+		}
+	}
+
+	m := NewManager(RunnerFunc(func(ctx context.Context, c job.Command, stdout, stderr io.Writer) (int, error) {
+		mu.Lock()
+		for _, required := range c.Requires {
+			startedEarlier[c.Name] = startedEarlier[c.Name] || !finished[required]
+		}
+		running++
+		if running > peak {
+			peak = running
+		}
+		mu.Unlock()
+
+		time.Sleep(time.Millisecond)
+
+		mu.Lock()
+		running--
+		finished[c.Name] = true
+		mu.Unlock()
+		return 0, nil
+	}), Options{MaxParallelism: 3})
+	r := m.Start(context.Background(), "chains", job.Plan{Parallelism: 2, Commands: commands})
+
+	s := waitRun(t, r)
+	assert.Equal(t, Succeeded, s.State)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.LessOrEqual(t, peak, 2)
+	assert.Len(t, finished, tasks)
+	for name, early := range startedEarlier {
+		assert.False(t, early, "task %s has started before its requires", name)
+	}
+}
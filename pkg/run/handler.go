@@ -0,0 +1,252 @@
+package run
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ivanspasov99/golang-api/pkg/job"
+	"github.com/ivanspasov99/golang-api/pkg/logging"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+var (
+	routeNotFoundErr = errors.New("route not found")
+
+	methodNotAllowedErr = errors.New("method not allowed")
+
+	invalidFollowErr = errors.New("invalid follow")
+)
+
+const (
+	jobsPrefix = "/jobs/"
+	runsPrefix = "/runs/"
+
+	followQuery = "follow"
+
+	// userHeader identifies the user who cancels the run
+	userHeader = "X-User"
+
+	eventStreamContentType = "text/event-stream"
+)
+
+// HandleCreate plans the job of the request as job.Handle does (the same query parameters are supported) and starts
+// its run in background. Responds with 202 and the status of the new run, the Location header points to it
+// Served on POST /jobs/{id}/runs, the job id is only recorded in the run
+func (m *Manager) HandleCreate(w http.ResponseWriter, r *http.Request) error {
+	jobID, ok := parsePath(r.URL.Path, jobsPrefix, "runs")
+	if !ok {
+		return fmt.Errorf("%w: %s", routeNotFoundErr, r.URL.Path)
+	}
+	if r.Method != http.MethodPost {
+		return fmt.Errorf("%w: %s", methodNotAllowedErr, r.Method)
+	}
+
+	// the job is decoded and validated as on /job, so the same bodies are rejected
+	j, err := job.DecodeJob(r.Body)
+	if err != nil {
+		return err
+	}
+	p, err := job.PlanJob(r, j)
+	if err != nil {
+		return err
+	}
+
+	// the run outlives the request, it is stopped with DELETE /runs/{id}
+	run := m.Start(detached{r.Context()}, jobID, p)
+	logging.Println(r.Context(), zerolog.InfoLevel, fmt.Sprintf("Run %s of job %s has been created", run.ID(), jobID))
+
+	w.Header().Set("Location", runsPrefix+run.ID())
+	return writeJSON(w, http.StatusAccepted, run.Status())
+}
+
+// HandleRuns serves GET /runs/{id} with the status of the run, DELETE /runs/{id} which cancels the run and
+// GET /runs/{id}/logs with its output
+func (m *Manager) HandleRuns(w http.ResponseWriter, r *http.Request) error {
+	id, ok := parsePath(r.URL.Path, runsPrefix)
+	logs := false
+	if !ok {
+		id, ok = parsePath(r.URL.Path, runsPrefix, "logs")
+		logs = true
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s", routeNotFoundErr, r.URL.Path)
+	}
+	if r.Method == http.MethodDelete && !logs {
+		return m.handleCancel(w, r, id)
+	}
+	if r.Method != http.MethodGet {
+		return fmt.Errorf("%w: %s", methodNotAllowedErr, r.Method)
+	}
+
+	run, err := m.Get(id)
+	if err != nil {
+		return err
+	}
+	if logs {
+		return handleLogs(w, r, run)
+	}
+	return writeJSON(w, http.StatusOK, run.Status())
+}
+
+// handleCancel cancels the run and responds with 202 and its status, the run is finished when the stopped commands
+// have exited. The cancelling user is taken from the X-User header or the remote address
+func (m *Manager) handleCancel(w http.ResponseWriter, r *http.Request, id string) error {
+	by := r.Header.Get(userHeader)
+	if by == "" {
+		by = r.RemoteAddr
+	}
+	run, err := m.Cancel(id, Cancellation{By: by, RequestID: w.Header().Get(logging.RequestIdHeader)})
+	if err != nil {
+		return err
+	}
+	logging.Println(r.Context(), zerolog.InfoLevel, fmt.Sprintf("Run %s has been cancelled by %s", id, by))
+	return writeJSON(w, http.StatusAccepted, run.Status())
+}
+
+// handleLogs streams the output of the run as Server-Sent Events, every line is event named after its stream
+// (stdout or stderr) with LogEntry as data and its seq as id. The output is replayed from the beginning or from
+// the Last-Event-ID header. With the query follow the new lines are streamed until the run finishes. The stream
+// of finished run ends with "end" event with the run status
+func handleLogs(w http.ResponseWriter, r *http.Request, run *Run) error {
+	follow, err := parseFollow(r)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", eventStreamContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher, _ := w.(http.Flusher)
+	flush := func() {
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	flush()
+
+	err = run.logs.follow(r.Context(), lastEventID(r)+1, follow, func(e LogEntry) error {
+		if err := writeEvent(w, strconv.FormatInt(e.Seq, 10), e.Stream, e); err != nil {
+			return err
+		}
+		flush()
+		return nil
+	})
+	// the response has been started, so the error could be only logged
+	if err != nil {
+		logging.Println(r.Context(), zerolog.InfoLevel, fmt.Sprintf("Logs of run %s have not been streamed to the end: %s", run.ID(), err))
+		return nil
+	}
+
+	select {
+	case <-run.Done():
+		if err := writeEvent(w, "", "end", run.Status()); err != nil {
+			logging.Println(r.Context(), zerolog.ErrorLevel, err.Error())
+		}
+		flush()
+	default:
+	}
+	return nil
+}
+
+// writeEvent writes single Server-Sent Event with json data, the id is left out when it is empty
+func writeEvent(w io.Writer, id, event string, data any) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if id != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+	return err
+}
+
+func parseFollow(r *http.Request) (bool, error) {
+	follow := r.URL.Query().Get(followQuery)
+	if follow == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(follow)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s, it should be true or false", invalidFollowErr, follow)
+	}
+	return b, nil
+}
+
+// lastEventID returns the seq of the last event received by the reconnecting client, -1 replays the whole output
+func lastEventID(r *http.Request) int64 {
+	seq, err := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64)
+	if err != nil || seq < 0 {
+		return -1
+	}
+	return seq
+}
+
+// parsePath returns the id which follows the prefix when the rest of the path matches the segments
+// Ex: parsePath("/jobs/build/runs", "/jobs/", "runs") returns "build"
+func parsePath(path, prefix string, segments ...string) (string, bool) {
+	if !strings.HasPrefix(path, prefix) {
+		return "", false
+	}
+	parts := strings.Split(strings.TrimPrefix(path, prefix), "/")
+	if len(parts) != len(segments)+1 || parts[0] == "" {
+		return "", false
+	}
+	for i, s := range segments {
+		if parts[i+1] != s {
+			return "", false
+		}
+	}
+	return parts[0], true
+}
+
+// HandleError is middleware which maps the run errors to 400, 404, 405 and 409, the rest are processed by
+// job.HandleError
+func HandleError(h job.HTTPTypeHandler) http.HandlerFunc {
+	return job.HandleError(func(w http.ResponseWriter, r *http.Request) error {
+		err := h(w, r)
+
+		status := http.StatusNotFound
+		switch {
+		case err == nil:
+			return nil
+		case errors.Is(err, runNotFoundErr), errors.Is(err, routeNotFoundErr):
+		case errors.Is(err, methodNotAllowedErr):
+			status = http.StatusMethodNotAllowed
+		case errors.Is(err, runFinishedErr):
+			status = http.StatusConflict
+		case errors.Is(err, invalidFollowErr):
+			status = http.StatusBadRequest
+			err = errors.Errorf("Please evaluate query parameters. Processing feedback: %s", err.Error())
+		default:
+			return err
+		}
+
+		logging.Println(r.Context(), zerolog.ErrorLevel, err.Error())
+		type ErrorResponse struct {
+			Message string `json:"Message"`
+		}
+		if err := writeJSON(w, status, ErrorResponse{Message: err.Error()}); err != nil {
+			logging.Println(r.Context(), zerolog.ErrorLevel, err.Error())
+		}
+		return nil
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, err = w.Write(b)
+	return err
+}
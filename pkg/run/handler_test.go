@@ -0,0 +1,284 @@
+package run
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ivanspasov99/golang-api/pkg/job"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+const testJob = `{"tasks":[
+	{"name":"task-1","command":"touch /tmp/file1"},
+	{"name":"task-2","command":"cat /tmp/file1","requires":["task-3"]},
+	{"name":"task-3","command":"echo 'Hello World!' > /tmp/file1","requires":["task-1"]},
+	{"name":"task-4","command":"rm /tmp/file1","requires":["task-2","task-3"]}
+]}`
+
+// exitCodes returns Runner which exits with the code of the task name, the rest exit with 0
+func exitCodes(codes map[string]int) Runner {
+	return RunnerFunc(func(ctx context.Context, c job.Command, stdout, stderr io.Writer) (int, error) {
+		return codes[c.Name], nil
+	})
+}
+
+func createRun(t *testing.T, m *Manager, path, body string) (*httptest.ResponseRecorder, Status) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	HandleError(m.HandleCreate).ServeHTTP(rr, req)
+
+	var s Status
+	if rr.Code == http.StatusAccepted {
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &s))
+	}
+	return rr, s
+}
+
+func getRun(t *testing.T, m *Manager, id string) Status {
+	t.Helper()
+	r, err := m.Get(id)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	select {
+	case <-r.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("run %s has not finished", id)
+	}
+
+	rr := httptest.NewRecorder()
+	HandleError(m.HandleRuns).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/runs/"+id, nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var s Status
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &s))
+	return s
+}
+
+func taskStates(s Status) map[string]State {
+	states := make(map[string]State, len(s.Tasks))
+	for _, t := range s.Tasks {
+		states[t.Name] = t.State
+	}
+	return states
+}
+
+func TestRunReportsTaskStates(t *testing.T) {
+	tests := []struct {
+		name     string
+		codes    map[string]int
+		expected State
+		states   map[string]State
+	}{
+		{
+			name:     "every task succeeds",
+			expected: Succeeded,
+			states:   map[string]State{"task-1": Succeeded, "task-3": Succeeded, "task-2": Succeeded, "task-4": Succeeded},
+		},
+		{
+			name:     "tasks which require the failing one are skipped",
+			codes:    map[string]int{"task-3": 2},
+			expected: Failed,
+			states:   map[string]State{"task-1": Succeeded, "task-3": Failed, "task-2": Skipped, "task-4": Skipped},
+		},
+		{
+			name:     "first task fails",
+			codes:    map[string]int{"task-1": 1},
+			expected: Failed,
+			states:   map[string]State{"task-1": Failed, "task-3": Skipped, "task-2": Skipped, "task-4": Skipped},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := NewManager(exitCodes(test.codes), Options{})
+			rr, created := createRun(t, m, "/jobs/build/runs", testJob)
+			assert.Equal(t, http.StatusAccepted, rr.Code)
+			assert.Equal(t, "/runs/"+created.ID, rr.Header().Get("Location"))
+			assert.Equal(t, "build", created.JobID)
+
+			s := getRun(t, m, created.ID)
+			assert.Equal(t, test.expected, s.State)
+			assert.Equal(t, test.states, taskStates(s))
+			assert.NotNil(t, s.StartedAt)
+			assert.NotNil(t, s.FinishedAt)
+
+			for _, task := range s.Tasks {
+				if task.State == Skipped {
+					assert.Nil(t, task.ExitCode)
+					assert.Nil(t, task.StartedAt)
+					continue
+				}
+				assert.Equal(t, test.codes[task.Name], *task.ExitCode)
+				assert.NotNil(t, task.StartedAt)
+				assert.NotNil(t, task.FinishedAt)
+			}
+		})
+	}
+}
+
+func TestRunExecutesSortedCommands(t *testing.T) {
+	executed := make(chan string, 4)
+	m := NewManager(RunnerFunc(func(ctx context.Context, c job.Command, stdout, stderr io.Writer) (int, error) {
+		executed <- c.Name
+		return 0, nil
+	}), Options{})
+
+	_, created := createRun(t, m, "/jobs/build/runs?order=lexical", testJob)
+	getRun(t, m, created.ID)
+	close(executed)
+
+	var order []string
+	for name := range executed {
+		order = append(order, name)
+	}
+	assert.Equal(t, []string{"task-1", "task-3", "task-2", "task-4"}, order)
+}
+
+func TestRunReportsStartError(t *testing.T) {
+	m := NewManager(ShellRunner{Shell: "/does/not/exist"}, Options{})
+	_, created := createRun(t, m, "/jobs/build/runs", `{"tasks":[{"name":"task-1","command":"true"}]}`)
+
+	s := getRun(t, m, created.ID)
+	assert.Equal(t, Failed, s.State)
+	assert.Equal(t, Failed, s.Tasks[0].State)
+	assert.Nil(t, s.Tasks[0].ExitCode)
+	assert.NotEmpty(t, s.Tasks[0].Error)
+}
+
+func TestManagerEvictsFinishedRuns(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+	m := NewManager(RunnerFunc(func(ctx context.Context, c job.Command, stdout, stderr io.Writer) (int, error) {
+		if c.Name == "block" {
+			<-block
+		}
+		return 0, nil
+	}), Options{Retention: time.Minute})
+	var mu sync.Mutex
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+	advance := func(d time.Duration) {
+		mu.Lock()
+		defer mu.Unlock()
+		now = now.Add(d)
+	}
+
+	finished := m.Start(context.Background(), "job", job.Plan{Commands: []job.Command{{Name: "a", Script: "a"}}})
+	waitRun(t, finished)
+	running := m.Start(context.Background(), "job", job.Plan{Commands: []job.Command{{Name: "block", Script: "block"}}})
+
+	advance(time.Minute)
+	_, err := m.Get(finished.ID())
+	assert.NoError(t, err, "the run is kept for the whole retention")
+
+	advance(time.Second)
+	_, err = m.Get(finished.ID())
+	assert.True(t, errors.Is(err, runNotFoundErr))
+	_, err = m.Get(running.ID())
+	assert.NoError(t, err, "the unfinished run is not evicted")
+
+	rr := httptest.NewRecorder()
+	HandleError(m.HandleRuns).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/runs/"+finished.ID(), nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestHandleCreateErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{name: "invalid job", method: http.MethodPost, path: "/jobs/build/runs", body: `{"tasks":[{"name":"task-1"}]}`, status: http.StatusBadRequest},
+		{name: "invalid command", method: http.MethodPost, path: "/jobs/build/runs", body: `{"tasks":[{"name":"task-1","command":"echo 'a"}]}`, status: http.StatusBadRequest},
+		{name: "unknown target", method: http.MethodPost, path: "/jobs/build/runs?target=task-9", body: testJob, status: http.StatusBadRequest},
+		{name: "missing job id", method: http.MethodPost, path: "/jobs//runs", body: testJob, status: http.StatusNotFound},
+		{name: "unknown route", method: http.MethodPost, path: "/jobs/build/other", body: testJob, status: http.StatusNotFound},
+		{name: "method not allowed", method: http.MethodGet, path: "/jobs/build/runs", status: http.StatusMethodNotAllowed},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := NewManager(exitCodes(nil), Options{})
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(test.method, test.path, strings.NewReader(test.body))
+			HandleError(m.HandleCreate).ServeHTTP(rr, req)
+
+			assert.Equal(t, test.status, rr.Code)
+			assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+			assert.Empty(t, m.runs)
+		})
+	}
+}
+
+func TestHandleCreateDecodesAsJobHandler(t *testing.T) {
+	for _, body := range []string{`{"tasks":[]} {}`, `{"tasks":{}}`, `{"tasks":[{"name":"task-1","command":"echo 1"}]`} {
+		t.Run(body, func(t *testing.T) {
+			expected := httptest.NewRecorder()
+			job.HandleError(job.Handle).ServeHTTP(expected, httptest.NewRequest(http.MethodPost, "/job", strings.NewReader(body)))
+
+			m := NewManager(exitCodes(nil), Options{})
+			rr, _ := createRun(t, m, "/jobs/build/runs", body)
+			assert.NotEqual(t, http.StatusOK, expected.Code)
+			assert.Equal(t, expected.Code, rr.Code)
+			assert.Empty(t, m.runs)
+		})
+	}
+}
+
+func TestHandleRunsErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		status int
+	}{
+		{name: "unknown run", method: http.MethodGet, path: "/runs/unknown", status: http.StatusNotFound},
+		{name: "missing id", method: http.MethodGet, path: "/runs/", status: http.StatusNotFound},
+		{name: "method not allowed", method: http.MethodPost, path: "/runs/unknown", status: http.StatusMethodNotAllowed},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			HandleError(NewManager(exitCodes(nil), Options{}).HandleRuns).ServeHTTP(rr, httptest.NewRequest(test.method, test.path, nil))
+			assert.Equal(t, test.status, rr.Code)
+		})
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path     string
+		prefix   string
+		segments []string
+		expected string
+		ok       bool
+	}{
+		{path: "/jobs/build/runs", prefix: jobsPrefix, segments: []string{"runs"}, expected: "build", ok: true},
+		{path: "/jobs/build/runs/", prefix: jobsPrefix, segments: []string{"runs"}},
+		{path: "/jobs/build", prefix: jobsPrefix, segments: []string{"runs"}},
+		{path: "/runs/id", prefix: runsPrefix, expected: "id", ok: true},
+		{path: "/runs/id/logs", prefix: runsPrefix},
+		{path: "/other/id", prefix: runsPrefix},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			id, ok := parsePath(test.path, test.prefix, test.segments...)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.expected, id)
+		})
+	}
+}
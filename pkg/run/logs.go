@@ -0,0 +1,158 @@
+package run
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	Stdout = "stdout"
+	Stderr = "stderr"
+
+	// maxLineLength splits the longer lines, so single line could not hold unlimited memory
+	maxLineLength = 64 * 1024
+)
+
+// LogEntry is single line of the task output
+type LogEntry struct {
+	// Seq is the position of the line in the run output, it is the SSE event id
+	Seq    int64  `json:"seq"`
+	Task   string `json:"task"`
+	Stream string `json:"stream"`
+	// Attempt is the number of the task execution which has written the line (see Attempt)
+	Attempt int       `json:"attempt"`
+	Time    time.Time `json:"time"`
+	Line    string    `json:"line"`
+}
+
+// logBuffer keeps the last lines of the run output in ring buffer, so the subscribers which come late could replay
+// the output from the beginning as long as it fits in the buffer. It is safe for concurrent use
+type logBuffer struct {
+	mu   sync.Mutex
+	ring []LogEntry
+	// next is the seq of the next entry, the entry with seq s is kept at ring[s % len(ring)]
+	next int64
+	// changed is closed and replaced on every append, so the subscribers could wait for new entries
+	changed chan struct{}
+	closed  bool
+}
+
+func newLogBuffer(lines int) *logBuffer {
+	if lines < 1 {
+		lines = 1
+	}
+	return &logBuffer{ring: make([]LogEntry, lines), changed: make(chan struct{})}
+}
+
+func (b *logBuffer) append(task, stream string, attempt int, line string, now time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.ring[b.next%int64(len(b.ring))] = LogEntry{Seq: b.next, Task: task, Stream: stream, Attempt: attempt, Time: now, Line: line}
+	b.next++
+	b.notify()
+}
+
+// close marks the end of the output, it is called when the run has finished
+func (b *logBuffer) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	b.notify()
+}
+
+// notify wakes up the subscribers, the caller holds the lock
+func (b *logBuffer) notify() {
+	close(b.changed)
+	b.changed = make(chan struct{})
+}
+
+// since returns the kept entries from seq on, the channel which is closed on the next change and whether
+// the output has ended. The entries which have already been dropped from the ring are left out
+func (b *logBuffer) since(seq int64) ([]LogEntry, <-chan struct{}, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if oldest := b.next - int64(len(b.ring)); seq < oldest {
+		seq = oldest
+	}
+	if seq < 0 {
+		seq = 0
+	}
+	var entries []LogEntry
+	for s := seq; s < b.next; s++ {
+		entries = append(entries, b.ring[s%int64(len(b.ring))])
+	}
+	return entries, b.changed, b.closed
+}
+
+// follow calls f with every entry from seq on, until the output ends or ctx is done. When follow is false only
+// the current entries are passed
+func (b *logBuffer) follow(ctx context.Context, seq int64, follow bool, f func(LogEntry) error) error {
+	for {
+		entries, changed, closed := b.since(seq)
+		for _, e := range entries {
+			if err := f(e); err != nil {
+				return err
+			}
+			seq = e.Seq + 1
+		}
+		if closed || !follow {
+			return nil
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// lineWriter is io.Writer which appends the output of the task stream to the buffer line by line
+// The incomplete line is kept until the next write or Flush
+type lineWriter struct {
+	buf     *logBuffer
+	task    string
+	stream  string
+	attempt int
+	now     func() time.Time
+	partial []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for i := bytes.IndexByte(p, '\n'); i >= 0; i = bytes.IndexByte(p, '\n') {
+		w.partial = append(w.partial, p[:i]...)
+		p = p[i+1:]
+		w.emit()
+	}
+	w.partial = append(w.partial, p...)
+	for len(w.partial) > maxLineLength {
+		w.buf.append(w.task, w.stream, w.attempt, string(w.partial[:maxLineLength]), w.now())
+		w.partial = w.partial[maxLineLength:]
+	}
+	return n, nil
+}
+
+// Flush appends the incomplete line, so the output of the task which does not end with new line is not lost
+func (w *lineWriter) Flush() {
+	if len(w.partial) > 0 {
+		w.emit()
+	}
+}
+
+// emit appends the kept line, the line longer than maxLineLength is split
+func (w *lineWriter) emit() {
+	line := w.partial
+	for len(line) > maxLineLength {
+		w.buf.append(w.task, w.stream, w.attempt, string(line[:maxLineLength]), w.now())
+		line = line[maxLineLength:]
+	}
+	w.buf.append(w.task, w.stream, w.attempt, string(line), w.now())
+	w.partial = w.partial[:0]
+}
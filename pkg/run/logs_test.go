@@ -0,0 +1,283 @@
+package run
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ivanspasov99/golang-api/pkg/job"
+	"github.com/stretchr/testify/assert"
+)
+
+var testTime = time.Date(2023, 3, 1, 10, 0, 0, 0, time.UTC)
+
+func lines(entries []LogEntry) []string {
+	var l []string
+	for _, e := range entries {
+		l = append(l, fmt.Sprintf("%d %s %s %s", e.Seq, e.Task, e.Stream, e.Line))
+	}
+	return l
+}
+
+func TestLineWriter(t *testing.T) {
+	tests := []struct {
+		name     string
+		writes   []string
+		expected []string
+	}{
+		{name: "complete lines", writes: []string{"a\nb\n"}, expected: []string{"0 t stdout a", "1 t stdout b"}},
+		{name: "line split between writes", writes: []string{"he", "llo\nwor", "ld"}, expected: []string{"0 t stdout hello", "1 t stdout world"}},
+		{name: "empty lines", writes: []string{"\n\na\n"}, expected: []string{"0 t stdout ", "1 t stdout ", "2 t stdout a"}},
+		{name: "long line", writes: []string{strings.Repeat("x", maxLineLength+1) + "\n"}, expected: []string{
+			"0 t stdout " + strings.Repeat("x", maxLineLength), "1 t stdout x",
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf := newLogBuffer(10)
+			w := &lineWriter{buf: buf, task: "t", stream: Stdout, now: func() time.Time { return testTime }}
+			for _, s := range test.writes {
+				n, err := w.Write([]byte(s))
+				assert.NoError(t, err)
+				assert.Equal(t, len(s), n)
+			}
+			w.Flush()
+
+			entries, _, _ := buf.since(0)
+			assert.Equal(t, test.expected, lines(entries))
+			for _, e := range entries {
+				assert.Equal(t, testTime, e.Time)
+			}
+		})
+	}
+}
+
+func TestLogBufferKeepsLastLines(t *testing.T) {
+	buf := newLogBuffer(3)
+	for i := 0; i < 5; i++ {
+		buf.append("t", Stderr, 1, fmt.Sprint(i), testTime)
+	}
+
+	entries, _, closed := buf.since(0)
+	assert.Equal(t, []string{"2 t stderr 2", "3 t stderr 3", "4 t stderr 4"}, lines(entries))
+	assert.False(t, closed)
+
+	entries, _, _ = buf.since(4)
+	assert.Equal(t, []string{"4 t stderr 4"}, lines(entries))
+
+	buf.close()
+	buf.append("t", Stderr, 1, "after close", testTime)
+	entries, _, closed = buf.since(5)
+	assert.Empty(t, entries)
+	assert.True(t, closed)
+}
+
+func TestLogBufferFollow(t *testing.T) {
+	buf := newLogBuffer(10)
+	buf.append("t", Stdout, 1, "first", testTime)
+
+	received := make(chan string)
+	done := make(chan error)
+	go func() {
+		done <- buf.follow(context.Background(), 0, true, func(e LogEntry) error {
+			received <- e.Line
+			return nil
+		})
+	}()
+
+	assert.Equal(t, "first", <-received)
+	buf.append("t", Stdout, 1, "second", testTime)
+	assert.Equal(t, "second", <-received)
+	buf.close()
+	assert.NoError(t, <-done)
+}
+
+func TestLogBufferFollowStopsOnContext(t *testing.T) {
+	buf := newLogBuffer(10)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- buf.follow(ctx, 0, true, func(e LogEntry) error { return nil })
+	}()
+
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
+
+// event is single parsed Server-Sent Event
+type event struct {
+	id    string
+	event string
+	data  string
+}
+
+func parseEvents(t *testing.T, r io.Reader) []event {
+	t.Helper()
+	var events []event
+	var e event
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			events = append(events, e)
+			e = event{}
+		case strings.HasPrefix(line, "id: "):
+			e.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			e.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			e.data = strings.TrimPrefix(line, "data: ")
+		default:
+			t.Errorf("unexpected line %q", line)
+		}
+	}
+	return events
+}
+
+func outputRunner(ctx context.Context, c job.Command, stdout, stderr io.Writer) (int, error) {
+	_, _ = fmt.Fprintf(stdout, "%s out\n", c.Name)
+	_, _ = fmt.Fprintf(stderr, "%s err", c.Name)
+	return 0, nil
+}
+
+func TestHandleLogsReplaysFinishedRun(t *testing.T) {
+	m := NewManager(RunnerFunc(outputRunner), Options{})
+	r := m.Start(context.Background(), "job", job.Plan{Commands: []job.Command{command("a"), command("b", "a")}})
+	waitRun(t, r)
+
+	tests := []struct {
+		name        string
+		query       string
+		lastEventID string
+		expectedIDs []string
+	}{
+		{name: "from the beginning", expectedIDs: []string{"0", "1", "2", "3", ""}},
+		{name: "follow", query: "?follow=true", expectedIDs: []string{"0", "1", "2", "3", ""}},
+		{name: "after last event id", lastEventID: "1", expectedIDs: []string{"2", "3", ""}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/runs/"+r.ID()+"/logs"+test.query, nil)
+			if test.lastEventID != "" {
+				req.Header.Set("Last-Event-ID", test.lastEventID)
+			}
+			HandleError(m.HandleRuns).ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, eventStreamContentType, rr.Header().Get("Content-Type"))
+			events := parseEvents(t, rr.Body)
+			var ids []string
+			for _, e := range events {
+				ids = append(ids, e.id)
+			}
+			assert.Equal(t, test.expectedIDs, ids)
+
+			end := events[len(events)-1]
+			assert.Equal(t, "end", end.event)
+			var s Status
+			assert.NoError(t, json.Unmarshal([]byte(end.data), &s))
+			assert.Equal(t, Succeeded, s.State)
+		})
+	}
+
+	rr := httptest.NewRecorder()
+	HandleError(m.HandleRuns).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/runs/"+r.ID()+"/logs", nil))
+	events := parseEvents(t, rr.Body)
+	var entries []LogEntry
+	for _, e := range events[:len(events)-1] {
+		var entry LogEntry
+		assert.NoError(t, json.Unmarshal([]byte(e.data), &entry))
+		assert.Equal(t, entry.Stream, e.event)
+		entries = append(entries, entry)
+	}
+	assert.Equal(t, []string{"0 a stdout a out", "1 a stderr a err", "2 b stdout b out", "3 b stderr b err"}, lines(entries))
+}
+
+func TestHandleLogsFollowsRunningRun(t *testing.T) {
+	runner := newBlockingRunner("a")
+	m := NewManager(RunnerFunc(func(ctx context.Context, c job.Command, stdout, stderr io.Writer) (int, error) {
+		_, _ = fmt.Fprintln(stdout, "before")
+		code, err := runner.Run(ctx, c, stdout, stderr)
+		_, _ = fmt.Fprintln(stdout, "after")
+		return code, err
+	}), Options{})
+	r := m.Start(context.Background(), "job", job.Plan{Commands: []job.Command{command("a")}})
+	runner.expectStarted(t)
+
+	// the stream without follow ends with the current lines and without end event
+	rr := httptest.NewRecorder()
+	HandleError(m.HandleRuns).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/runs/"+r.ID()+"/logs", nil))
+	events := parseEvents(t, rr.Body)
+	assert.Len(t, events, 1)
+	assert.Equal(t, Stdout, events[0].event)
+
+	pr, pw := io.Pipe()
+	followed := make(chan []event)
+	go func() {
+		followed <- parseEvents(t, pr)
+	}()
+	go func() {
+		w := &pipeResponseWriter{header: make(http.Header), Writer: pw}
+		HandleError(m.HandleRuns).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/runs/"+r.ID()+"/logs?follow=1", nil))
+		_ = pw.Close()
+	}()
+
+	runner.release["a"] <- 3
+	events = <-followed
+	var names []string
+	for _, e := range events {
+		names = append(names, e.event)
+	}
+	assert.Equal(t, []string{Stdout, Stdout, "end"}, names)
+	assert.Contains(t, events[1].data, `"line":"after"`)
+	assert.Contains(t, events[2].data, `"state":"failed"`)
+}
+
+// pipeResponseWriter is http.ResponseWriter which writes the body to the pipe, so the test reads the events while
+// they are streamed
+type pipeResponseWriter struct {
+	io.Writer
+	header http.Header
+}
+
+func (w *pipeResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *pipeResponseWriter) WriteHeader(int) {}
+
+func TestHandleLogsErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		status int
+	}{
+		{name: "unknown run", path: "/runs/unknown/logs", status: http.StatusNotFound},
+		{name: "unknown route", path: "/runs/unknown/other", status: http.StatusNotFound},
+		{name: "invalid follow", path: "/runs/%s/logs?follow=always", status: http.StatusBadRequest},
+	}
+	m := NewManager(RunnerFunc(outputRunner), Options{})
+	r := m.Start(context.Background(), "job", job.Plan{Commands: []job.Command{command("a")}})
+	waitRun(t, r)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := test.path
+			if strings.Contains(path, "%s") {
+				path = fmt.Sprintf(path, r.ID())
+			}
+			rr := httptest.NewRecorder()
+			HandleError(m.HandleRuns).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+			assert.Equal(t, test.status, rr.Code)
+			assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/ivanspasov99/golang-api/pkg/job"
+	"github.com/pkg/errors"
+)
+
+var (
+	runNotFoundErr = errors.New("run not found")
+
+	runFinishedErr = errors.New("run has already finished")
+)
+
+// defaultLogLines is the size of the log buffer of the run when Options.LogLines is not set
+const defaultLogLines = 10000
+
+// defaultRetention is how long the finished run is kept when Options.Retention is not set
+const defaultRetention = time.Hour
+
+// shutdownBy records the runs cancelled by Shutdown
+const shutdownBy = "server shutdown"
+
+// Options configures the Manager
+type Options struct {
+	// MaxParallelism is the maximum number of tasks running at the same time in all runs, 0 is unlimited
+	MaxParallelism int
+	// LogLines is the number of the last output lines kept for every run (see logBuffer)
+	LogLines int
+	// Retention is how long the finished run is kept, then it is evicted and not found anymore
+	Retention time.Duration
+}
+
+// Manager starts the runs in background and keeps them in memory, so their status could be requested later
+// The finished runs are evicted after the retention (see evict). It is safe for concurrent use
+type Manager struct {
+	mu   sync.RWMutex
+	runs map[string]*Run
+
+	runner Runner
+	// slots limits the number of tasks running at the same time in all runs, it is nil when unlimited
+	slots     chan struct{}
+	logLines  int
+	retention time.Duration
+	// now and after are replaced in tests
+	now   func() time.Time
+	after func(time.Duration) <-chan time.Time
+}
+
+// NewManager returns Manager which executes the commands with the runner
+func NewManager(runner Runner, o Options) *Manager {
+	m := &Manager{
+		runs:      make(map[string]*Run),
+		runner:    runner,
+		logLines:  o.LogLines,
+		retention: o.Retention,
+		now:       time.Now,
+		after:     time.After,
+	}
+	if m.logLines <= 0 {
+		m.logLines = defaultLogLines
+	}
+	if m.retention <= 0 {
+		m.retention = defaultRetention
+	}
+	if o.MaxParallelism > 0 {
+		m.slots = make(chan struct{}, o.MaxParallelism)
+	}
+	return m
+}
+
+// Start stores new run of the plan and executes its commands in background goroutine (see execute)
+// At most p.Parallelism tasks of the run are running at the same time, 0 is unlimited
+// The run is cancelled when ctx is done, the caller which wants the run to outlive the request should pass
+// context which is not cancelled with it
+func (m *Manager) Start(ctx context.Context, jobID string, p job.Plan) *Run {
+	r := newRun(uuid.New().String(), jobID, p.Commands, p.Parallelism, m.logLines, m.now())
+	ctx, r.cancel = context.WithCancel(ctx)
+
+	m.mu.Lock()
+	m.evict()
+	m.runs[r.ID()] = r
+	m.mu.Unlock()
+
+	go m.execute(ctx, r)
+	return r
+}
+
+// Cancel cancels the run, the running commands are stopped (see Runner) and the rest are not started
+// Returns runNotFoundErr or runFinishedErr
+func (m *Manager) Cancel(id string, c Cancellation) (*Run, error) {
+	r, err := m.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if c.At.IsZero() {
+		c.At = m.now()
+	}
+	if err := r.requestCancel(c); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Shutdown cancels every unfinished run and waits until they finish or ctx is done, so the server does not leave
+// running commands behind
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.RLock()
+	runs := make([]*Run, 0, len(m.runs))
+	for _, r := range m.runs {
+		runs = append(runs, r)
+	}
+	m.mu.RUnlock()
+
+	for _, r := range runs {
+		_ = r.requestCancel(Cancellation{By: shutdownBy, At: m.now()})
+	}
+	for _, r := range runs {
+		select {
+		case <-r.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Get returns the run by id or runNotFoundErr
+func (m *Manager) Get(id string) (*Run, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.evict()
+	r, ok := m.runs[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", runNotFoundErr, id)
+	}
+	return r, nil
+}
+
+// evict removes the runs which have finished before more than the retention, so the memory of the manager does not
+// grow with every run. It is called with the lock held, when the runs are stored or requested
+func (m *Manager) evict() {
+	deadline := m.now().Add(-m.retention)
+	for id, r := range m.runs {
+		if finishedAt, ok := r.finishedAt(); ok && finishedAt.Before(deadline) {
+			delete(m.runs, id)
+		}
+	}
+}
+
+// detached keeps the values of the request context, but it is never cancelled, so the run outlives the request
+type detached struct {
+	context.Context
+}
+
+func (detached) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detached) Done() <-chan struct{} {
+	return nil
+}
+
+func (detached) Err() error {
+	return nil
+}
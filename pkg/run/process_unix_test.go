@@ -0,0 +1,88 @@
+//go:build !windows
+
+package run
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/ivanspasov99/golang-api/pkg/job"
+	"github.com/stretchr/testify/assert"
+)
+
+// startShell runs the script with ShellRunner in background and returns the first line of its output and channel
+// with its exit code
+func startShell(t *testing.T, ctx context.Context, runner ShellRunner, script string) (string, <-chan int) {
+	t.Helper()
+	buf := newLogBuffer(10)
+	stdout := &lineWriter{buf: buf, task: "task", stream: Stdout, now: time.Now}
+	code := make(chan int, 1)
+	go func() {
+		c, _ := runner.Run(ctx, job.Command{Name: "task", Script: script}, stdout, stdout)
+		code <- c
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if entries, _, _ := buf.since(0); len(entries) > 0 {
+			return entries[0].Line, code
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("script has not written its first line")
+	return "", nil
+}
+
+func TestShellRunnerTerminatesProcessGroup(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	// the script starts child process and prints its pid
+	line, code := startShell(t, ctx, ShellRunner{GracePeriod: time.Minute}, "sleep 60 & echo $!; wait")
+	child, err := strconv.Atoi(strings.TrimSpace(line))
+	assert.NoError(t, err)
+
+	cancel()
+	select {
+	case c := <-code:
+		assert.Equal(t, -1, c)
+	case <-time.After(5 * time.Second):
+		t.Fatal("command has not been terminated")
+	}
+	// the child is terminated as well, so it does not exist or it is zombie which is not reaped yet
+	assert.Eventually(t, func() bool {
+		return syscall.Kill(child, 0) != nil || isZombie(child)
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestShellRunnerKillsAfterGracePeriod(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	grace := 200 * time.Millisecond
+	// SIGTERM is ignored by the shell and its children
+	_, code := startShell(t, ctx, ShellRunner{GracePeriod: grace}, "trap '' TERM; echo started; while true; do sleep 0.05; done")
+
+	cancelled := time.Now()
+	cancel()
+	select {
+	case c := <-code:
+		assert.Equal(t, -1, c)
+		assert.GreaterOrEqual(t, time.Since(cancelled), grace)
+	case <-time.After(5 * time.Second):
+		t.Fatal("command has not been killed")
+	}
+}
+
+// isZombie reports whether the process has exited but it is not reaped by its parent yet
+func isZombie(pid int) bool {
+	b, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return true
+	}
+	stat := string(b)
+	// the state follows the command name in parentheses, Ex: 42 (sleep) Z ...
+	i := strings.LastIndex(stat, ")")
+	return i >= 0 && strings.HasPrefix(stat[i+1:], " Z")
+}
@@ -0,0 +1,20 @@
+//go:build windows
+
+package run
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup does nothing on windows, only the shell process is stopped
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// terminateProcessGroup kills p as windows does not support SIGTERM
+func terminateProcessGroup(p *os.Process) error {
+	return p.Kill()
+}
+
+func killProcessGroup(p *os.Process) error {
+	return p.Kill()
+}
@@ -0,0 +1,159 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ivanspasov99/golang-api/pkg/job"
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyRunner exits with the exit codes of the task name one after another, then with 0
+func flakyRunner(codes map[string][]int) Runner {
+	var mu sync.Mutex
+	return RunnerFunc(func(ctx context.Context, c job.Command, stdout, stderr io.Writer) (int, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(codes[c.Name]) == 0 {
+			_, _ = fmt.Fprintln(stdout, "succeeded")
+			return 0, nil
+		}
+		code := codes[c.Name][0]
+		codes[c.Name] = codes[c.Name][1:]
+		_, _ = fmt.Fprintf(stderr, "failed with %d\n", code)
+		return code, nil
+	})
+}
+
+// recordDelays replaces the delays of the manager, they are recorded and elapse immediately
+func recordDelays(m *Manager) func() []time.Duration {
+	var mu sync.Mutex
+	var delays []time.Duration
+	m.after = func(d time.Duration) <-chan time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		delays = append(delays, d)
+		ch := make(chan time.Time, 1)
+		ch <- time.Time{}
+		return ch
+	}
+	return func() []time.Duration {
+		mu.Lock()
+		defer mu.Unlock()
+		return delays
+	}
+}
+
+func attemptCodes(t TaskStatus) []int {
+	var codes []int
+	for _, a := range t.Attempts {
+		codes = append(codes, *a.ExitCode)
+	}
+	return codes
+}
+
+func TestExecuteRetriesFailingTask(t *testing.T) {
+	m := NewManager(flakyRunner(map[string][]int{"task-1": {7, 7}}), Options{})
+	delays := recordDelays(m)
+
+	body := `{"tasks":[
+		{"name":"task-1","command":"curl example.com","retry":{"maxAttempts":3,"initialDelay":"1s","maxDelay":"1m"}},
+		{"name":"task-2","command":"echo done","requires":["task-1"]}
+	]}`
+	_, created := createRun(t, m, "/jobs/build/runs", body)
+	s := getRun(t, m, created.ID)
+
+	assert.Equal(t, Succeeded, s.State)
+	assert.Equal(t, map[string]State{"task-1": Succeeded, "task-2": Succeeded}, taskStates(s))
+	assert.Equal(t, []int{7, 7, 0}, attemptCodes(s.Tasks[0]))
+	assert.Equal(t, []int{1, 2, 3}, []int{s.Tasks[0].Attempts[0].Number, s.Tasks[0].Attempts[1].Number, s.Tasks[0].Attempts[2].Number})
+	assert.Equal(t, 0, *s.Tasks[0].ExitCode)
+	assert.Equal(t, []int{0}, attemptCodes(s.Tasks[1]))
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second}, delays())
+
+	r, _ := m.Get(created.ID)
+	entries, _, _ := r.logs.since(0)
+	var attempts []int
+	for _, e := range entries {
+		if e.Task == "task-1" {
+			attempts = append(attempts, e.Attempt)
+		}
+	}
+	assert.Equal(t, []int{1, 2, 3}, attempts)
+}
+
+func TestExecuteRetryPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		codes    []int
+		retry    *job.Retry
+		expected State
+		attempts []int
+	}{
+		{
+			name:     "attempts run out",
+			codes:    []int{7, 7, 7},
+			retry:    &job.Retry{MaxAttempts: 2},
+			expected: Failed,
+			attempts: []int{7, 7},
+		},
+		{
+			name:     "exit code is not retried",
+			codes:    []int{7},
+			retry:    &job.Retry{MaxAttempts: 3, RetryOn: []int{75}},
+			expected: Failed,
+			attempts: []int{7},
+		},
+		{
+			name:     "listed exit code is retried",
+			codes:    []int{75},
+			retry:    &job.Retry{MaxAttempts: 3, RetryOn: []int{75}},
+			expected: Succeeded,
+			attempts: []int{75, 0},
+		},
+		{
+			name:     "task without policy runs once",
+			codes:    []int{1},
+			expected: Failed,
+			attempts: []int{1},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := NewManager(flakyRunner(map[string][]int{"a": test.codes}), Options{})
+			recordDelays(m)
+			r := m.Start(context.Background(), "job", job.Plan{Commands: []job.Command{
+				{Name: "a", Script: "a", Retry: test.retry},
+			}})
+
+			s := waitRun(t, r)
+			assert.Equal(t, test.expected, s.State)
+			assert.Equal(t, test.attempts, attemptCodes(s.Tasks[0]))
+		})
+	}
+}
+
+func TestCancelDuringRetryDelay(t *testing.T) {
+	m := NewManager(flakyRunner(map[string][]int{"a": {7}}), Options{})
+	waiting := make(chan time.Duration, 1)
+	m.after = func(d time.Duration) <-chan time.Time {
+		waiting <- d
+		return nil
+	}
+	r := m.Start(context.Background(), "job", job.Plan{Commands: []job.Command{
+		{Name: "a", Script: "a", Retry: &job.Retry{MaxAttempts: 3, InitialDelay: "1h"}},
+	}})
+
+	assert.Equal(t, time.Hour, <-waiting)
+	_, err := m.Cancel(r.ID(), Cancellation{By: "alice"})
+	assert.NoError(t, err)
+
+	s := waitRun(t, r)
+	assert.Equal(t, Cancelled, s.State)
+	assert.Equal(t, Cancelled, s.Tasks[0].State)
+	assert.Equal(t, []int{7}, attemptCodes(s.Tasks[0]))
+}
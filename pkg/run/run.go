@@ -0,0 +1,243 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ivanspasov99/golang-api/pkg/job"
+)
+
+// State is the state of a run or of a single task of the run
+type State string
+
+const (
+	Pending   State = "pending"
+	Running   State = "running"
+	Succeeded State = "succeeded"
+	Failed    State = "failed"
+	// Skipped is used only for tasks, they are not executed as some of their required tasks has failed
+	Skipped State = "skipped"
+	// Cancelled tasks have not been started or have been stopped as the run has been cancelled
+	// The run is cancelled when some of its tasks are
+	Cancelled State = "cancelled"
+)
+
+// Cancellation records who has cancelled the run and when
+type Cancellation struct {
+	// By is the user of DELETE /runs/{id} or the reason of the context cancellation, Ex: server shutdown
+	By string    `json:"by"`
+	At time.Time `json:"at"`
+	// RequestID is the id of the cancelling request (see logging.RequestIdHeader)
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// TaskStatus is the state of the task, the exit code and the times are set when the task is executed
+// The exit code and the error are the ones of the last attempt
+type TaskStatus struct {
+	Name       string     `json:"name"`
+	State      State      `json:"state"`
+	ExitCode   *int       `json:"exitCode,omitempty"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+	// Error is set when the command could not be started
+	Error string `json:"error,omitempty"`
+	// Attempts lists every execution of the command, there are more of them when the task has retry policy
+	Attempts []Attempt `json:"attempts,omitempty"`
+}
+
+// Attempt is single execution of the task command
+type Attempt struct {
+	// Number is 1 for the first execution
+	Number     int       `json:"number"`
+	ExitCode   *int      `json:"exitCode,omitempty"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Status is the snapshot of the run returned by GET /runs/{id}
+type Status struct {
+	ID    string `json:"id"`
+	JobID string `json:"jobId"`
+	// State is the overall result, it is succeeded only when every task has succeeded
+	State      State        `json:"state"`
+	CreatedAt  time.Time    `json:"createdAt"`
+	StartedAt  *time.Time   `json:"startedAt,omitempty"`
+	FinishedAt *time.Time   `json:"finishedAt,omitempty"`
+	Tasks      []TaskStatus `json:"tasks"`
+	// Cancellation is set when the run has been cancelled
+	Cancellation *Cancellation `json:"cancellation,omitempty"`
+}
+
+// Run is single execution of the planned job, it is safe for concurrent use
+// The executor updates it while the handlers read its Status
+type Run struct {
+	mu       sync.RWMutex
+	status   Status
+	commands []job.Command
+	// parallelism is the maximum number of running tasks of the run, 0 is unlimited
+	parallelism int
+	// index maps the task name to its position in status.Tasks
+	index map[string]int
+	// logs keeps the output of the tasks
+	logs *logBuffer
+	// cancel cancels the context of the run, the running commands are stopped then
+	cancel context.CancelFunc
+	// done is closed when the run has finished
+	done chan struct{}
+}
+
+func newRun(id, jobID string, commands []job.Command, parallelism, logLines int, now time.Time) *Run {
+	r := &Run{
+		status: Status{
+			ID:        id,
+			JobID:     jobID,
+			State:     Pending,
+			CreatedAt: now,
+			Tasks:     make([]TaskStatus, len(commands)),
+		},
+		commands:    commands,
+		parallelism: parallelism,
+		index:       make(map[string]int, len(commands)),
+		logs:        newLogBuffer(logLines),
+		done:        make(chan struct{}),
+	}
+	for i, c := range commands {
+		r.status.Tasks[i] = TaskStatus{Name: c.Name, State: Pending}
+		r.index[c.Name] = i
+	}
+	return r
+}
+
+// ID returns the id of the run
+func (r *Run) ID() string {
+	return r.status.ID
+}
+
+// Done returns channel which is closed when the run has finished
+func (r *Run) Done() <-chan struct{} {
+	return r.done
+}
+
+// finishedAt returns the time when the run has finished, false when it is not finished yet
+func (r *Run) finishedAt() (time.Time, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.status.FinishedAt == nil {
+		return time.Time{}, false
+	}
+	return *r.status.FinishedAt, true
+}
+
+// Status returns copy of the current status, so it could be encoded while the run is updated
+func (r *Run) Status() Status {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s := r.status
+	s.Tasks = make([]TaskStatus, len(r.status.Tasks))
+	copy(s.Tasks, r.status.Tasks)
+	for i, t := range s.Tasks {
+		s.Tasks[i].Attempts = append([]Attempt(nil), t.Attempts...)
+	}
+	if c := r.status.Cancellation; c != nil {
+		cancellation := *c
+		s.Cancellation = &cancellation
+	}
+	return s
+}
+
+// requestCancel records the cancellation and cancels the context of the run. The first cancellation is kept when
+// the run is cancelled again. Returns runFinishedErr when the run has already finished
+func (r *Run) requestCancel(c Cancellation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.status.FinishedAt != nil {
+		return fmt.Errorf("%w: %s is %s", runFinishedErr, r.status.ID, r.status.State)
+	}
+	if r.status.Cancellation == nil {
+		r.status.Cancellation = &c
+	}
+	r.cancel()
+	return nil
+}
+
+func (r *Run) start(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status.State = Running
+	r.status.StartedAt = &now
+}
+
+// finish sets the overall result, closes done and ends the output, so the end of the output means finished run
+// The context of the run is released
+func (r *Run) finish(state State, now time.Time) {
+	r.mu.Lock()
+	r.status.State = state
+	r.status.FinishedAt = &now
+	r.mu.Unlock()
+	r.cancel()
+	close(r.done)
+	r.logs.close()
+}
+
+func (r *Run) startTask(name string, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t := &r.status.Tasks[r.index[name]]
+	t.State = Running
+	t.StartedAt = &now
+}
+
+// finishAttempt records the attempt of the task
+func (r *Run) finishAttempt(name string, a Attempt) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t := &r.status.Tasks[r.index[name]]
+	t.Attempts = append(t.Attempts, a)
+}
+
+// finishTask records the exit code of the task, err is set when the command could not be started. The failed task
+// of cancelled run is cancelled, as it has been stopped
+func (r *Run) finishTask(name string, exitCode int, err error, cancelled bool, now time.Time) State {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t := &r.status.Tasks[r.index[name]]
+	t.FinishedAt = &now
+	t.State = Succeeded
+	if err != nil {
+		t.State = Failed
+		t.Error = err.Error()
+	} else {
+		t.ExitCode = &exitCode
+		if exitCode != 0 {
+			t.State = Failed
+		}
+	}
+	if t.State == Failed && cancelled {
+		t.State = Cancelled
+	}
+	return t.State
+}
+
+// cancelPending marks the tasks which have not been started as cancelled, reports whether there were such tasks
+func (r *Run) cancelPending() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cancelled := false
+	for i := range r.status.Tasks {
+		if r.status.Tasks[i].State == Pending {
+			r.status.Tasks[i].State = Cancelled
+			cancelled = true
+		}
+	}
+	return cancelled
+}
+
+func (r *Run) skipTask(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status.Tasks[r.index[name]].State = Skipped
+}
@@ -0,0 +1,101 @@
+package run
+
+import (
+	"context"
+	"io"
+	"os/exec"
+	"time"
+
+	"github.com/ivanspasov99/golang-api/pkg/job"
+	"github.com/pkg/errors"
+)
+
+const (
+	// defaultShell runs the commands of ShellRunner when its Shell is not set
+	defaultShell = "bash"
+
+	// defaultGracePeriod is used when ShellRunner.GracePeriod is not set
+	defaultGracePeriod = 10 * time.Second
+)
+
+// Runner executes single command, writes its output to stdout and stderr and returns its exit code. The error is
+// returned only when the command could not be executed at all, non zero exit code is not an error
+// The command should be stopped when ctx is done
+type Runner interface {
+	Run(ctx context.Context, c job.Command, stdout, stderr io.Writer) (int, error)
+}
+
+// RunnerFunc is an adapter to allow the use of ordinary functions as Runner
+type RunnerFunc func(ctx context.Context, c job.Command, stdout, stderr io.Writer) (int, error)
+
+// Run calls f(ctx, c, stdout, stderr)
+func (f RunnerFunc) Run(ctx context.Context, c job.Command, stdout, stderr io.Writer) (int, error) {
+	return f(ctx, c, stdout, stderr)
+}
+
+// ShellRunner runs the script of the command with `Shell -c` in its own process group
+type ShellRunner struct {
+	Shell string
+	// GracePeriod is the time between SIGTERM and SIGKILL when the command is stopped
+	GracePeriod time.Duration
+}
+
+// Run starts the shell with os/exec and waits for it and for its output. When ctx is done SIGTERM is sent to the
+// process group of the shell and SIGKILL after the grace period, so the processes started by the script are stopped
+// as well. The exit code of the stopped command is -1
+func (s ShellRunner) Run(ctx context.Context, c job.Command, stdout, stderr io.Writer) (int, error) {
+	if err := ctx.Err(); err != nil {
+		return -1, err
+	}
+	shell := s.Shell
+	if shell == "" {
+		shell = defaultShell
+	}
+
+	cmd := exec.Command(shell, "-c", c.Script)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return -1, err
+	}
+
+	exited := make(chan struct{})
+	go s.stop(ctx, cmd, exited)
+	err := cmd.Wait()
+	close(exited)
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return 0, nil
+	case errors.As(err, &exitErr):
+		return exitErr.ExitCode(), nil
+	default:
+		return -1, err
+	}
+}
+
+// stop terminates the process group of the command when ctx is done and kills it when it has not exited in the
+// grace period. Wait returns only when the output pipes are closed, so the group is killed even when only the shell
+// has exited and its children still run
+func (s ShellRunner) stop(ctx context.Context, cmd *exec.Cmd, exited <-chan struct{}) {
+	select {
+	case <-ctx.Done():
+	case <-exited:
+		return
+	}
+	_ = terminateProcessGroup(cmd.Process)
+
+	grace := s.GracePeriod
+	if grace <= 0 {
+		grace = defaultGracePeriod
+	}
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		_ = killProcessGroup(cmd.Process)
+	case <-exited:
+	}
+}
@@ -0,0 +1,39 @@
+package run
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ivanspasov99/golang-api/pkg/job"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShellRunner(t *testing.T) {
+	tests := []struct {
+		name     string
+		runner   ShellRunner
+		script   string
+		expected int
+		stdout   string
+		stderr   string
+		hasError bool
+	}{
+		{name: "success", script: "true", expected: 0},
+		{name: "exit code", script: "echo failing >&2; exit 3", expected: 3, stderr: "failing\n"},
+		{name: "output", script: "echo out; echo err >&2; printf partial", expected: 0, stdout: "out\npartial", stderr: "err\n"},
+		{name: "bash script", script: "set -o pipefail; false | true", expected: 1},
+		{name: "custom shell", runner: ShellRunner{Shell: "sh"}, script: "exit 4", expected: 4},
+		{name: "missing shell", runner: ShellRunner{Shell: "/does/not/exist"}, script: "true", expected: -1, hasError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr strings.Builder
+			code, err := test.runner.Run(context.Background(), job.Command{Name: "task", Script: test.script}, &stdout, &stderr)
+			assert.Equal(t, test.expected, code)
+			assert.Equal(t, test.stdout, stdout.String())
+			assert.Equal(t, test.stderr, stderr.String())
+			assert.Equal(t, test.hasError, err != nil)
+		})
+	}
+}